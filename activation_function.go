@@ -3,8 +3,8 @@ package neurgo
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"math"
+	"sync"
 )
 
 type ActivationFunction func(float64) float64
@@ -12,45 +12,141 @@ type ActivationFunction func(float64) float64
 type EncodableActivation struct {
 	Name               string
 	ActivationFunction ActivationFunction
+	Params             []float64 // for activations which take parameters, eg LeakyRelu
+}
+
+// Builds an activation function from its parameters.  See
+// RegisterParameterizedActivation().
+type ActivationFactory func(params []float64) (ActivationFunction, error)
+
+// Activation functions by name, so that they can be restored when a
+// network is deserialized.  See RegisterActivation().
+var activationRegistry = struct {
+	sync.RWMutex
+	factories map[string]ActivationFactory
+}{factories: make(map[string]ActivationFactory)}
+
+func init() {
+	RegisterActivation("sigmoid", Sigmoid)
+	RegisterActivation("tanh", Tanh)
+	RegisterActivation("identity", Identity)
+	RegisterActivation("relu", Relu)
+	RegisterParameterizedActivation("leaky_relu", func(params []float64) (ActivationFunction, error) {
+		if len(params) != 1 {
+			return nil, fmt.Errorf("leaky_relu takes 1 parameter, got %v", params)
+		}
+		return LeakyRelu(params[0]), nil
+	})
+}
+
+// Make an activation function available by name when unmarshalling,
+// replacing any already registered under it.
+func RegisterActivation(name string, fn ActivationFunction) {
+	RegisterParameterizedActivation(name, func(params []float64) (ActivationFunction, error) {
+		if len(params) != 0 {
+			return nil, fmt.Errorf("%v takes no parameters, got %v", name, params)
+		}
+		return fn, nil
+	})
+}
+
+// Same as RegisterActivation, but the factory builds the function from
+// the Params saved with the name.
+func RegisterParameterizedActivation(name string, factory ActivationFactory) {
+	activationRegistry.Lock()
+	defer activationRegistry.Unlock()
+	activationRegistry.factories[name] = factory
+}
+
+// Remove the activation function registered under the given name, if any
+func unregisterActivation(name string) {
+	activationRegistry.Lock()
+	defer activationRegistry.Unlock()
+	delete(activationRegistry.factories, name)
+}
+
+// Find the activation function registered under the given name
+func LookupActivation(name string) (*EncodableActivation, error) {
+	return LookupParameterizedActivation(name, nil)
+}
+
+// Find the activation function registered under the given name, and build
+// it with the given parameters.
+func LookupParameterizedActivation(name string, params []float64) (*EncodableActivation, error) {
+	activationRegistry.RLock()
+	factory, ok := activationRegistry.factories[name]
+	activationRegistry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown activation function: %q (see RegisterActivation)", name)
+	}
+	fn, err := factory(params)
+	if err != nil {
+		return nil, err
+	}
+	return &EncodableActivation{
+		Name:               name,
+		ActivationFunction: fn,
+		Params:             params,
+	}, nil
 }
 
 func (activation *EncodableActivation) MarshalJSON() ([]byte, error) {
+	if len(activation.Params) == 0 {
+		return json.Marshal(
+			struct {
+				Name string
+			}{
+				Name: activation.Name,
+			})
+	}
 	return json.Marshal(
 		struct {
-			Name string
+			Name   string
+			Params []float64
 		}{
-			Name: activation.Name,
+			Name:   activation.Name,
+			Params: activation.Params,
 		})
 }
 
 func (activation *EncodableActivation) UnmarshalJSON(bytes []byte) error {
 
-	rawMap := make(map[string]interface{})
-	err := json.Unmarshal(bytes, &rawMap)
-	if err != nil {
+	encoded := struct {
+		Name   *string
+		Params []float64
+	}{}
+	if err := json.Unmarshal(bytes, &encoded); err != nil {
 		return err
 	}
-
-	// TODO: isn't there an easier / less brittle way to do this??
-	var ok bool
-	if activation.Name, ok = rawMap["Name"].(string); !ok {
-		log.Panicf("Could not unmarshal %v into EncodableActivation", rawMap)
+	if encoded.Name == nil {
+		return fmt.Errorf("could not unmarshal %s into EncodableActivation", bytes)
 	}
 
-	switch activation.Name {
-	case "sigmoid":
-		activation.ActivationFunction = Sigmoid
-	case "tanh":
-		activation.ActivationFunction = math.Tanh
-	case "identity":
-		activation.ActivationFunction = Identity
-	default:
-		log.Panicf("Unknown activation function: %v", activation.Name)
+	registered, err := LookupParameterizedActivation(*encoded.Name, encoded.Params)
+	if err != nil {
+		return err
 	}
+	*activation = *registered
 
 	return nil
 }
 
+// An activation which can be applied to a whole slice of values at once,
+// leaving room for implementations that vectorize the computation.
+type BatchActivation interface {
+	ApplyBatch(xs []float64) []float64
+}
+
+// Apply the activation function to each of the values in turn, returning
+// the results in a new slice.
+func (activation *EncodableActivation) ApplyBatch(xs []float64) []float64 {
+	result := make([]float64, len(xs))
+	for i, x := range xs {
+		result[i] = activation.ActivationFunction(x)
+	}
+	return result
+}
+
 func (activation *EncodableActivation) String() string {
 	return fmt.Sprintf("%v (%v)", activation.Name, activation.ActivationFunction)
 }
@@ -77,10 +173,45 @@ func EncodableIdentity() *EncodableActivation {
 	}
 }
 
+// Like Sigmoid but centered on zero, with outputs in (-1, 1)
+func Tanh(x float64) float64 {
+	return math.Tanh(x)
+}
+
 func EncodableTanh() *EncodableActivation {
 	return &EncodableActivation{
 		Name:               "tanh",
-		ActivationFunction: math.Tanh,
+		ActivationFunction: Tanh,
+	}
+}
+
+func Relu(x float64) float64 {
+	return math.Max(0, x)
+}
+
+func EncodableRelu() *EncodableActivation {
+	return &EncodableActivation{
+		Name:               "relu",
+		ActivationFunction: Relu,
+	}
+}
+
+// Like Relu, but with a slope of alpha rather than 0 for negative inputs,
+// so that neurons can't get stuck outputting 0.
+func LeakyRelu(alpha float64) ActivationFunction {
+	return func(x float64) float64 {
+		if x > 0 {
+			return x
+		}
+		return alpha * x
+	}
+}
+
+func EncodableLeakyRelu(alpha float64) *EncodableActivation {
+	return &EncodableActivation{
+		Name:               "leaky_relu",
+		ActivationFunction: LeakyRelu(alpha),
+		Params:             []float64{alpha},
 	}
 }
 
@@ -93,3 +224,19 @@ func RandomEncodableActivation() *EncodableActivation {
 	randIndex := RandomIntInRange(0, len(allActivations))
 	return allActivations[randIndex]
 }
+
+// Do a and b agree to within tolerance at evenly spaced points across
+// sampleRange?
+func ActivationsEqual(a, b ActivationFunction, sampleRange [2]float64, samples int, tolerance float64) bool {
+	step := float64(0)
+	if samples > 1 {
+		step = (sampleRange[1] - sampleRange[0]) / float64(samples-1)
+	}
+	for i := 0; i < samples; i++ {
+		x := sampleRange[0] + float64(i)*step
+		if !EqualsWithMaxDelta(a(x), b(x), tolerance) {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/couchbaselabs/go.assert"
 	"log"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -43,3 +45,116 @@ func TestActivationFunctionUnmarshal(t *testing.T) {
 	assert.True(t, encodableActivation.ActivationFunction != nil)
 
 }
+
+func TestTanh(t *testing.T) {
+
+	assert.Equals(t, Tanh(0), 0.0)
+	for x := -5.0; x <= 5.0; x += 0.5 {
+		assert.True(t, Tanh(x) > -1 && Tanh(x) < 1)
+	}
+	assert.True(t, Tanh(2) > Tanh(1))
+
+	// it survives a round trip through json, and mutations can pick it
+	activation := &EncodableActivation{}
+	assert.True(t, json.Unmarshal([]byte(`{"Name":"tanh"}`), activation) == nil)
+	assert.Equals(t, activation.ActivationFunction(0.5), Tanh(0.5))
+	names := make([]string, 0)
+	for _, activation := range AllEncodableActivations() {
+		names = append(names, activation.Name)
+	}
+	assert.Equals(t, names, []string{"sigmoid", "tanh"})
+
+}
+
+func TestRelu(t *testing.T) {
+
+	relu := EncodableRelu()
+	assert.Equals(t, relu.ActivationFunction(-2), 0.0)
+	assert.Equals(t, relu.ActivationFunction(3), 3.0)
+
+	leakyRelu := EncodableLeakyRelu(0.1)
+	assert.Equals(t, leakyRelu.ActivationFunction(3), 3.0)
+	assert.True(t, EqualsWithMaxDelta(leakyRelu.ActivationFunction(-2), -0.2, 1e-12))
+
+	// the slope is saved along with the name
+	jsonBytes, err := json.Marshal(leakyRelu)
+	assert.True(t, err == nil)
+	assert.Equals(t, string(jsonBytes), `{"Name":"leaky_relu","Params":[0.1]}`)
+	decoded := &EncodableActivation{}
+	assert.True(t, json.Unmarshal(jsonBytes, decoded) == nil)
+	assert.Equals(t, decoded.Params, []float64{0.1})
+	assert.True(t, EqualsWithMaxDelta(decoded.ActivationFunction(-2), -0.2, 1e-12))
+
+	// activations without parameters are saved as before
+	jsonBytes, err = json.Marshal(relu)
+	assert.True(t, err == nil)
+	assert.Equals(t, string(jsonBytes), `{"Name":"relu"}`)
+
+	// the wrong number of parameters
+	assert.True(t, json.Unmarshal([]byte(`{"Name":"leaky_relu"}`), decoded) != nil)
+	assert.True(t, json.Unmarshal([]byte(`{"Name":"relu","Params":[1]}`), decoded) != nil)
+
+}
+
+func TestActivationsEqual(t *testing.T) {
+	sampleRange := [2]float64{-10, 10}
+	assert.True(t, ActivationsEqual(Sigmoid, Sigmoid, sampleRange, 100, 1e-9))
+	assert.True(t, ActivationsEqual(Sigmoid, EncodableSigmoid().ActivationFunction, sampleRange, 100, 1e-9))
+	assert.False(t, ActivationsEqual(Sigmoid, math.Tanh, sampleRange, 100, 1e-3))
+
+	// sigmoid(x) == (tanh(x/2) + 1) / 2
+	scaledTanh := func(x float64) float64 {
+		return (math.Tanh(x/2) + 1) / 2
+	}
+	assert.True(t, ActivationsEqual(Sigmoid, scaledTanh, sampleRange, 100, 1e-9))
+}
+
+func TestActivationApplyBatch(t *testing.T) {
+
+	xs := []float64{-10, -1.5, 0, 0.25, 3, 40}
+
+	var batch BatchActivation = EncodableSigmoid()
+	results := batch.ApplyBatch(xs)
+
+	assert.Equals(t, len(results), len(xs))
+	for i, x := range xs {
+		assert.Equals(t, results[i], Sigmoid(x))
+	}
+
+	// the inputs are left alone
+	assert.Equals(t, xs[0], -10.0)
+
+}
+
+func TestActivationRegistry(t *testing.T) {
+
+	activation, err := LookupActivation("sigmoid")
+	assert.True(t, err == nil)
+	assert.Equals(t, activation.ActivationFunction(0), 0.5)
+
+	_, err = LookupActivation("softsign")
+	assert.True(t, err != nil)
+	err = json.Unmarshal([]byte(`{"Name":"softsign"}`), &EncodableActivation{})
+	assert.True(t, err != nil)
+
+	softsign := func(x float64) float64 {
+		return x / (1 + math.Abs(x))
+	}
+	RegisterActivation("softsign", softsign)
+	t.Cleanup(func() {
+		unregisterActivation("softsign")
+	})
+
+	encodableActivation := &EncodableActivation{}
+	err = json.Unmarshal([]byte(`{"Name":"softsign"}`), encodableActivation)
+	assert.True(t, err == nil)
+	assert.Equals(t, encodableActivation.Name, "softsign")
+	assert.Equals(t, encodableActivation.ActivationFunction(1), 0.5)
+
+	// a whole cortex fails to load, rather than ending up with a neuron
+	// that can't fire
+	jsonString := strings.Replace(exampleCortexJson(), `"Name":"sigmoid"`, `"Name":"bogus"`, 1)
+	_, err = NewCortexFromJSONString(jsonString)
+	assert.True(t, err != nil)
+
+}
@@ -17,10 +17,15 @@ type Actuator struct {
 	DataChan         chan *DataMessage
 	VectorLength     int
 	ActuatorFunction ActuatorFunction
+	OutputChan       chan<- []float64 // if set, every output is sent here too
 	wg               *sync.WaitGroup
 	Cortex           *Cortex
+	combine          CombineFunction // see EnsembleCortex()
 }
 
+// Combines the outputs of several networks into a single output vector
+type CombineFunction func(outputs [][]float64) []float64
+
 func (actuator *Actuator) Init() {
 	if actuator.Closing == nil {
 		actuator.Closing = make(chan chan bool)
@@ -36,6 +41,10 @@ func (actuator *Actuator) Init() {
 		actuatorFunc := func(outputs []float64) {
 			log.Panicf("defualt actuator function called - do nothing")
 		}
+		if actuator.OutputChan != nil {
+			// the outputs are consumed from the channel instead
+			actuatorFunc = func(outputs []float64) {}
+		}
 		actuator.ActuatorFunction = actuatorFunc
 	}
 
@@ -51,6 +60,7 @@ func (actuator *Actuator) Run() {
 	defer actuator.wg.Done()
 
 	actuator.checkRunnable()
+	actuator.Cortex.nodeRunning(actuator.NodeId)
 
 	weightedInputs := createEmptyWeightedInputs(actuator.Inbound)
 
@@ -77,13 +87,25 @@ func (actuator *Actuator) Run() {
 		if receiveBarrierSatisfied(weightedInputs) {
 
 			scalarOutput := actuator.computeScalarOutput(weightedInputs)
+			if actuator.combine != nil {
+				scalarOutput = actuator.combineOutputs(scalarOutput)
+			}
 			actuator.ActuatorFunction(scalarOutput)
+			if actuator.OutputChan != nil {
+				// blocks until the consumer is ready for it, which holds
+				// up the rest of the network too
+				actuator.Cortex.sending(actuator.NodeId, "OutputChan")
+				actuator.OutputChan <- scalarOutput
+				actuator.Cortex.sending(actuator.NodeId, "")
+			}
 
 			if actuator.Cortex != nil && actuator.Cortex.SyncChan != nil {
 				logmsg := fmt.Sprintf("%v -> %v", actuator.NodeId.UUID, actuator.Cortex.NodeId.UUID)
 				logg.LogTo("ACTUATOR_SYNC", logmsg)
 
+				actuator.Cortex.sending(actuator.NodeId, "cortex SyncChan")
 				actuator.Cortex.SyncChan <- actuator.NodeId
+				actuator.Cortex.sending(actuator.NodeId, "")
 			} else {
 				logg.LogTo("ACTUATOR_SYNC", "Could not sync actuator: %v", actuator)
 			}
@@ -147,6 +169,20 @@ func (actuator *Actuator) computeScalarOutput(weightedInputs []*weightedInput) [
 
 }
 
+// Split the inputs into consecutive groups of VectorLength, one for each
+// network feeding into this actuator, and combine them into one output.
+func (actuator *Actuator) combineOutputs(outputs []float64) []float64 {
+	groups := make([][]float64, 0)
+	for i := 0; i < len(outputs); i += actuator.VectorLength {
+		groups = append(groups, outputs[i:i+actuator.VectorLength])
+	}
+	combined := actuator.combine(groups)
+	if len(combined) != actuator.VectorLength {
+		log.Panicf("Combined output %v does not have length %d", combined, actuator.VectorLength)
+	}
+	return combined
+}
+
 func (actuator *Actuator) validateInputs(inputs []float64) {
 	if len(inputs) != 1 {
 		t := "%T got invalid input vector: %v"
@@ -176,7 +212,14 @@ func (actuator *Actuator) checkRunnable() {
 		panic(msg)
 	}
 
-	if len(actuator.Inbound) != actuator.VectorLength {
+	if actuator.combine != nil {
+		if len(actuator.Inbound)%actuator.VectorLength != 0 {
+			msg := fmt.Sprintf("# of inbound (%d) not a multiple of VectorLength (%d)",
+				len(actuator.Inbound),
+				actuator.VectorLength)
+			panic(msg)
+		}
+	} else if len(actuator.Inbound) != actuator.VectorLength {
 		msg := fmt.Sprintf("# of inbound (%d) != VectorLength (%d)",
 			len(actuator.Inbound),
 			actuator.VectorLength)
@@ -64,3 +64,30 @@ func TestActuatorRun(t *testing.T) {
 	assert.True(t, vectorEqualsWithMaxDelta(collectedActuatorVal, fakeInput, 0.1))
 
 }
+
+func TestActuatorOutputChan(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	xnorCortex.Sensors[0].SensorFunction = func(syncCounter int) []float64 {
+		return []float64{1, 1}
+	}
+
+	// no ActuatorFunction needed when the outputs go to a channel
+	outputChan := make(chan []float64)
+	actuator := xnorCortex.Actuators[0]
+	actuator.ActuatorFunction = nil
+	actuator.OutputChan = outputChan
+
+	err := xnorCortex.Start()
+	assert.True(t, err == nil)
+
+	for i := 0; i < 2; i++ {
+		xnorCortex.SyncSensors()
+		output := <-outputChan
+		assert.True(t, vectorEqualsWithMaxDelta(output, []float64{1}, 0.01))
+		xnorCortex.SyncActuators()
+	}
+
+	xnorCortex.Shutdown()
+
+}
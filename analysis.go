@@ -0,0 +1,446 @@
+package neurgo
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// The variance of each actuator output (concatenated in actuator order)
+// across the samples.  Near zero usually means a collapsed network.
+func (cortex *Cortex) OutputVariance(samples []*TrainingSample) []float64 {
+
+	outputs := cortex.runSamples(samples)
+
+	// collect the values seen for each output, across all samples
+	outputValues := make([][]float64, 0)
+	for _, sampleOutputs := range outputs {
+		outputIndex := 0
+		for _, actuatorOutputs := range sampleOutputs {
+			for _, value := range actuatorOutputs {
+				if outputIndex == len(outputValues) {
+					outputValues = append(outputValues, make([]float64, 0))
+				}
+				outputValues[outputIndex] = append(outputValues[outputIndex], value)
+				outputIndex += 1
+			}
+		}
+	}
+
+	variances := make([]float64, len(outputValues))
+	for i, values := range outputValues {
+		variances[i] = Variance(values)
+	}
+	return variances
+
+}
+
+// The average output of each neuron across the samples, keyed by UUID.
+func (cortex *Cortex) MeanActivations(samples []*TrainingSample) map[string]float64 {
+
+	meanActivations := make(map[string]float64)
+	for uuid, neuronOutputs := range cortex.neuronOutputs(samples) {
+		meanActivations[uuid] = Average(neuronOutputs)
+	}
+	return meanActivations
+
+}
+
+// The mean absolute neuron output in each layer for a single pass, to spot
+// where the signal is vanishing.
+func (cortex *Cortex) SignalMagnitudeByLayer(inputs [][]float64) map[float64]float64 {
+
+	var mutex sync.Mutex
+	magnitudes := make(map[float64][]float64)
+	observer := &runObserver{
+		neuronFired: func(neuron *Neuron, weightedSum, output float64) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			layerIndex := neuron.NodeId.LayerIndex
+			magnitudes[layerIndex] = append(magnitudes[layerIndex], math.Abs(output))
+		},
+	}
+	cortex.observe(observer, func() {
+		cortex.runPasses([][][]float64{inputs})
+	})
+
+	meanMagnitudes := make(map[float64]float64)
+	for layerIndex, layerMagnitudes := range magnitudes {
+		meanMagnitudes[layerIndex] = Average(layerMagnitudes)
+	}
+	return meanMagnitudes
+
+}
+
+// The sorted names of the distinct activation functions in each layer.
+func (cortex *Cortex) ActivationDiversity() map[float64][]string {
+
+	diversity := make(map[float64][]string)
+	for layerIndex, neurons := range cortex.NeuronLayerMap() {
+		seen := make(map[string]bool)
+		names := make([]string, 0)
+		for _, neuron := range neurons {
+			name := neuron.ActivationFunction.Name
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		diversity[layerIndex] = names
+	}
+	return diversity
+
+}
+
+// Run the samples through the network and return the outputs of each
+// neuron, in the order it produced them, keyed by neuron UUID.
+func (cortex *Cortex) neuronOutputs(samples []*TrainingSample) map[string][]float64 {
+
+	var mutex sync.Mutex
+	outputs := make(map[string][]float64)
+	observer := &runObserver{
+		neuronFired: func(neuron *Neuron, weightedSum, output float64) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			outputs[neuron.NodeId.UUID] = append(outputs[neuron.NodeId.UUID], output)
+		},
+	}
+	cortex.observe(observer, func() {
+		cortex.runSamples(samples)
+	})
+	return outputs
+
+}
+
+// The Pearson correlation between the outputs of neurons a and b across
+// the samples (NaN if either output never changes).
+func (cortex *Cortex) NeuronCorrelation(a, b *NodeId, samples []*TrainingSample) float64 {
+
+	outputs := cortex.neuronOutputs(samples)
+	for _, nodeId := range []*NodeId{a, b} {
+		if _, ok := outputs[nodeId.UUID]; !ok {
+			log.Panicf("Neuron %v did not fire", nodeId.UUID)
+		}
+	}
+	return PearsonCorrelation(outputs[a.UUID], outputs[b.UUID])
+
+}
+
+// How far Fitness drops when each input of the (single) sensor is shuffled
+// across the samples using rng.
+func (cortex *Cortex) PermutationImportance(samples []*TrainingSample, rng *rand.Rand) []float64 {
+
+	if len(samples) == 0 {
+		return []float64{}
+	}
+
+	baseline := cortex.Fitness(samples)
+
+	numInputs := len(samples[0].SampleInputs[0])
+	importances := make([]float64, numInputs)
+	for dimension := 0; dimension < numInputs; dimension++ {
+
+		permuted := make([]*TrainingSample, len(samples))
+		donors := rng.Perm(len(samples))
+		for i, sample := range samples {
+			inputs := make([]float64, numInputs)
+			copy(inputs, sample.SampleInputs[0])
+			donor := samples[donors[i]]
+			inputs[dimension] = donor.SampleInputs[0][dimension]
+			permuted[i] = &TrainingSample{
+				SampleInputs:    [][]float64{inputs},
+				ExpectedOutputs: sample.ExpectedOutputs,
+			}
+		}
+
+		importances[dimension] = baseline - cortex.Fitness(permuted)
+	}
+	return importances
+
+}
+
+// The outputs (concatenated in actuator order) as one input is swept over
+// values, with the rest of baseInput held fixed.
+func (cortex *Cortex) PartialDependence(inputSensor string, inputIndex int, values []float64, baseInput [][]float64) [][]float64 {
+
+	sensorIndex := -1
+	for i, sensor := range cortex.Sensors {
+		if sensor.NodeId.UUID == inputSensor {
+			sensorIndex = i
+		}
+	}
+	if sensorIndex == -1 {
+		log.Panicf("No sensor with UUID %v", inputSensor)
+	}
+	if sensorIndex >= len(baseInput) || inputIndex < 0 || inputIndex >= len(baseInput[sensorIndex]) {
+		log.Panicf("Input index %d is out of range for sensor %v", inputIndex, inputSensor)
+	}
+
+	dependence := make([][]float64, len(values))
+	for i, value := range values {
+
+		inputs := make([][]float64, len(baseInput))
+		for j, sensorInput := range baseInput {
+			inputs[j] = make([]float64, len(sensorInput))
+			copy(inputs[j], sensorInput)
+		}
+		inputs[sensorIndex][inputIndex] = value
+
+		outputs, err := cortex.Activate(inputs)
+		if err != nil {
+			log.Panicf("Could not activate cortex: %v", err)
+		}
+		dependence[i] = make([]float64, 0)
+		for _, actuatorOutputs := range outputs {
+			dependence[i] = append(dependence[i], actuatorOutputs...)
+		}
+	}
+	return dependence
+
+}
+
+// Do this cortex and the reference agree to within tolerance on every
+// sample?
+func (cortex *Cortex) OutputsMatch(reference *Cortex, samples []*TrainingSample, tolerance float64) bool {
+
+	outputs := cortex.runSamples(samples)
+	referenceOutputs := reference.runSamples(samples)
+
+	for i, sampleOutputs := range outputs {
+		if len(sampleOutputs) != len(referenceOutputs[i]) {
+			return false
+		}
+		for j, actuatorOutputs := range sampleOutputs {
+			if len(actuatorOutputs) != len(referenceOutputs[i][j]) {
+				return false
+			}
+			for k, output := range actuatorOutputs {
+				if !EqualsWithMaxDelta(output, referenceOutputs[i][j][k], tolerance) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+
+}
+
+// The largest norm, over the samples, of the gradient of the outputs with
+// respect to the inputs, estimated with central differences.
+func (cortex *Cortex) MaxGradientNorm(samples []*TrainingSample, epsilon float64) float64 {
+
+	// the outputs of all actuators for a single pass, concatenated
+	activate := func(inputs [][]float64) []float64 {
+		outputs := make([]float64, 0)
+		for _, actuatorOutputs := range cortex.runPasses([][][]float64{inputs})[0] {
+			outputs = append(outputs, actuatorOutputs...)
+		}
+		return outputs
+	}
+
+	// a copy of the inputs with one element shifted by delta
+	shifted := func(inputs [][]float64, sensorIndex, index int, delta float64) [][]float64 {
+		result := make([][]float64, len(inputs))
+		for i, sensorInputs := range inputs {
+			result[i] = make([]float64, len(sensorInputs))
+			copy(result[i], sensorInputs)
+		}
+		result[sensorIndex][index] += delta
+		return result
+	}
+
+	maxNorm := float64(0)
+	for _, sample := range samples {
+		sumOfSquares := float64(0)
+		for sensorIndex, sensorInputs := range sample.SampleInputs {
+			for index, _ := range sensorInputs {
+				above := activate(shifted(sample.SampleInputs, sensorIndex, index, epsilon))
+				below := activate(shifted(sample.SampleInputs, sensorIndex, index, -1*epsilon))
+				for i, _ := range above {
+					gradient := (above[i] - below[i]) / (2 * epsilon)
+					sumOfSquares += gradient * gradient
+				}
+			}
+		}
+		maxNorm = math.Max(maxNorm, math.Sqrt(sumOfSquares))
+	}
+	return maxNorm
+
+}
+
+// The neurons which fired whose activation stays within tolerance of its
+// tangent at zero over every weighted sum the samples produce.
+func (cortex *Cortex) LinearNeurons(samples []*TrainingSample, tolerance float64) []*NodeId {
+
+	var mutex sync.Mutex
+	weightedSums := make(map[string][]float64)
+	observer := &runObserver{
+		neuronFired: func(neuron *Neuron, weightedSum, output float64) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			weightedSums[neuron.NodeId.UUID] = append(weightedSums[neuron.NodeId.UUID], weightedSum)
+		},
+	}
+	cortex.observe(observer, func() {
+		cortex.runSamples(samples)
+	})
+
+	h := 1e-6
+	linearNeurons := make([]*NodeId, 0)
+	for _, neuron := range cortex.SortedNeurons() {
+		neuronWeightedSums, ok := weightedSums[neuron.NodeId.UUID]
+		if !ok {
+			continue
+		}
+		activation := neuron.ActivationFunction.ActivationFunction
+		intercept := activation(0)
+		slope := (activation(h) - activation(-1*h)) / (2 * h)
+		linear := true
+		for _, x := range neuronWeightedSums {
+			if !EqualsWithMaxDelta(activation(x), intercept+slope*x, tolerance) {
+				linear = false
+				break
+			}
+		}
+		if linear {
+			linearNeurons = append(linearNeurons, neuron.NodeId)
+		}
+	}
+	return linearNeurons
+
+}
+
+// Do the nodes give the same outputs every time over the given number of
+// fresh runs on the same inputs?
+func (cortex *Cortex) IsDeterministic(inputs [][]float64, trials int) bool {
+
+	tolerance := 1e-12
+
+	var expected [][]float64
+	for i := 0; i < trials; i++ {
+		outputs := cortex.runPasses([][][]float64{inputs})[0]
+		if expected == nil {
+			expected = outputs
+			continue
+		}
+		for j, actuatorOutputs := range outputs {
+			if !vectorEqualsWithMaxDelta(actuatorOutputs, expected[j], tolerance) {
+				return false
+			}
+		}
+	}
+	return true
+
+}
+
+// The relevance of each neuron and sensor to one output (counted across
+// all actuators), shared out in proportion to weighted contributions.
+func (cortex *Cortex) LayerwiseRelevance(inputs [][]float64, outputIndex int) map[string]float64 {
+
+	if err := cortex.checkStateless(); err != nil {
+		log.Panicf("Cannot compute relevance: %v", err)
+	}
+	if len(inputs) != len(cortex.Sensors) {
+		log.Panicf("Got %d input vectors for %d sensors", len(inputs), len(cortex.Sensors))
+	}
+	for i, sensor := range cortex.Sensors {
+		if len(inputs[i]) != sensor.VectorLength {
+			log.Panicf("Input vector %v has length %d, expected %d", inputs[i], len(inputs[i]), sensor.VectorLength)
+		}
+	}
+
+	var outputNodeId *NodeId
+	index := 0
+	for _, actuator := range cortex.Actuators {
+		for _, inbound := range actuator.Inbound {
+			if index == outputIndex {
+				outputNodeId = inbound.NodeId
+			}
+			index += 1
+		}
+	}
+	if outputNodeId == nil {
+		log.Panicf("Output index %d is out of range", outputIndex)
+	}
+
+	neurons := cortex.SortedNeurons()
+	outputs, _ := cortex.forwardPass(neurons, inputs)
+
+	relevance := make(map[string]float64)
+	for _, sensor := range cortex.Sensors {
+		relevance[sensor.NodeId.UUID] = 0
+	}
+	for _, neuron := range neurons {
+		relevance[neuron.NodeId.UUID] = 0
+	}
+	relevance[outputNodeId.UUID] = outputs[outputNodeId.UUID][0]
+
+	// keeps the shares finite when the contributions cancel out
+	epsilon := 1e-9
+
+	for i := len(neurons) - 1; i >= 0; i-- {
+		neuron := neurons[i]
+		total := 0.0
+		for _, inbound := range neuron.Inbound {
+			if inbound.Disabled {
+				continue
+			}
+			for j, weight := range inbound.Weights {
+				total += outputs[inbound.NodeId.UUID][j] * weight
+			}
+		}
+		if total >= 0 {
+			total += epsilon
+		} else {
+			total -= epsilon
+		}
+		for _, inbound := range neuron.Inbound {
+			if inbound.Disabled {
+				continue
+			}
+			for j, weight := range inbound.Weights {
+				contribution := outputs[inbound.NodeId.UUID][j] * weight
+				relevance[inbound.NodeId.UUID] += relevance[neuron.NodeId.UUID] * contribution / total
+			}
+		}
+	}
+
+	return relevance
+
+}
+
+// Group the neurons whose outputs agree to within tolerance on every
+// sample, in SortedNeurons order.
+func (cortex *Cortex) FindRedundantNeurons(samples []*TrainingSample, tolerance float64) [][]*NodeId {
+
+	outputs := cortex.neuronOutputs(samples)
+
+	groups := make([][]*NodeId, 0)
+	for _, neuron := range cortex.SortedNeurons() {
+		neuronOutputs := outputs[neuron.NodeId.UUID]
+		grouped := false
+		for i, group := range groups {
+			groupOutputs := outputs[group[0].UUID]
+			if len(neuronOutputs) == len(groupOutputs) && vectorEqualsWithMaxDelta(neuronOutputs, groupOutputs, tolerance) {
+				groups[i] = append(group, neuron.NodeId)
+				grouped = true
+				break
+			}
+		}
+		if !grouped {
+			groups = append(groups, []*NodeId{neuron.NodeId})
+		}
+	}
+
+	redundant := make([][]*NodeId, 0)
+	for _, group := range groups {
+		if len(group) > 1 {
+			redundant = append(redundant, group)
+		}
+	}
+	return redundant
+
+}
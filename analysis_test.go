@@ -0,0 +1,338 @@
+package neurgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/couchbaselabs/go.assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestOutputVariance(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+
+	// zero out the weights so the neuron always outputs sigmoid(0)
+	constantCortex := BasicCortex()
+	constantCortex.Neurons[0].Inbound[0].Weights = []float64{0, 0}
+
+	variances := constantCortex.OutputVariance(examples)
+	assert.Equals(t, len(variances), 1)
+	assert.True(t, EqualsWithMaxDelta(variances[0], 0.0, 1e-9))
+
+	// xnor outputs are split between 0 and 1, so the variance is ~0.25
+	xnorCortex := XnorCortex()
+	variances = xnorCortex.OutputVariance(examples)
+	assert.Equals(t, len(variances), 1)
+	assert.True(t, EqualsWithMaxDelta(variances[0], 0.25, 0.01))
+
+}
+
+func TestMeanActivations(t *testing.T) {
+
+	// feed the same input every time, so each neuron's mean activation
+	// is just its fixed output for that input
+	constantInput := &TrainingSample{
+		SampleInputs:    [][]float64{[]float64{0, 0}},
+		ExpectedOutputs: [][]float64{[]float64{1}},
+	}
+	samples := []*TrainingSample{constantInput, constantInput, constantInput}
+
+	xnorCortex := XnorCortex()
+	meanActivations := xnorCortex.MeanActivations(samples)
+	assert.Equals(t, len(meanActivations), 3)
+
+	hidden1 := Sigmoid(-30)
+	hidden2 := Sigmoid(10)
+	output := Sigmoid(20*hidden1 + 20*hidden2 - 10)
+	assert.True(t, EqualsWithMaxDelta(meanActivations["hidden-neuron1"], hidden1, 1e-12))
+	assert.True(t, EqualsWithMaxDelta(meanActivations["hidden-neuron2"], hidden2, 1e-12))
+	assert.True(t, EqualsWithMaxDelta(meanActivations["output-neuron"], output, 1e-12))
+
+	// over the xnor samples, hidden-neuron1 only fires for (1, 1)
+	meanActivations = xnorCortex.MeanActivations(XnorTrainingSamples())
+	assert.True(t, EqualsWithMaxDelta(meanActivations["hidden-neuron1"], 0.25, 0.01))
+
+}
+
+func TestPermutationImportance(t *testing.T) {
+
+	// the output follows the first input and ignores the second
+	cortex := BasicCortex()
+	cortex.Neurons[0].Inbound[0].Weights = []float64{20, 0}
+	cortex.Neurons[0].Bias = -10
+
+	samples := []*TrainingSample{
+		&TrainingSample{
+			SampleInputs:    [][]float64{[]float64{0, 0}},
+			ExpectedOutputs: [][]float64{[]float64{0}},
+		},
+		&TrainingSample{
+			SampleInputs:    [][]float64{[]float64{0, 1}},
+			ExpectedOutputs: [][]float64{[]float64{0}},
+		},
+		&TrainingSample{
+			SampleInputs:    [][]float64{[]float64{1, 0}},
+			ExpectedOutputs: [][]float64{[]float64{1}},
+		},
+		&TrainingSample{
+			SampleInputs:    [][]float64{[]float64{1, 1}},
+			ExpectedOutputs: [][]float64{[]float64{1}},
+		},
+	}
+
+	// with this seed the shuffle moves the first input's values around
+	importances := cortex.PermutationImportance(samples, rand.New(rand.NewSource(2)))
+	assert.Equals(t, len(importances), 2)
+	assert.True(t, importances[0] > importances[1])
+	assert.True(t, importances[0] > 0)
+	assert.Equals(t, importances[1], 0.0)
+
+	// the same shuffles give the same importances
+	assert.Equals(t, cortex.PermutationImportance(samples, rand.New(rand.NewSource(2))), importances)
+
+}
+
+func TestPartialDependence(t *testing.T) {
+
+	// a single sigmoid neuron with positive weights is monotonic in both
+	// of its inputs
+	cortex := BasicCortex()
+	cortex.Neurons[0].Inbound[0].Weights = []float64{1, 2}
+
+	values := []float64{-3, -1, 0, 0.5, 2, 4}
+	baseInput := [][]float64{[]float64{0.25, 0.75}}
+	dependence := cortex.PartialDependence("sensor", 1, values, baseInput)
+
+	assert.Equals(t, len(dependence), len(values))
+	for i, outputs := range dependence {
+		assert.Equals(t, len(outputs), 1)
+		assert.True(t, EqualsWithMaxDelta(outputs[0], Sigmoid(0.25+2*values[i]), 1e-12))
+		if i > 0 {
+			assert.True(t, outputs[0] > dependence[i-1][0])
+		}
+	}
+
+	// the base input is left alone
+	assert.Equals(t, baseInput[0][1], 0.75)
+
+}
+
+func TestOutputsMatch(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+	xnorCortex := XnorCortex()
+	assert.True(t, xnorCortex.OutputsMatch(xnorCortex.Copy(), examples, 1e-12))
+
+	perturbed := xnorCortex.Copy()
+	perturbed.Neurons[2].Bias = -11
+	assert.False(t, xnorCortex.OutputsMatch(perturbed, examples, 1e-6))
+
+	// but it's close enough for a loose tolerance
+	assert.True(t, xnorCortex.OutputsMatch(perturbed, examples, 0.01))
+
+}
+
+func TestMaxGradientNorm(t *testing.T) {
+
+	samples := []*TrainingSample{
+		&TrainingSample{
+			SampleInputs:    [][]float64{[]float64{0, 0}},
+			ExpectedOutputs: [][]float64{[]float64{0.5}},
+		},
+		&TrainingSample{
+			SampleInputs:    [][]float64{[]float64{1, -1}},
+			ExpectedOutputs: [][]float64{[]float64{0.5}},
+		},
+	}
+
+	smooth := BasicCortex()
+	smooth.Neurons[0].Inbound[0].Weights = []float64{1, 1}
+
+	// at 0 the sigmoid's slope is 1/4, so the gradient is (1/4, 1/4)
+	smoothNorm := smooth.MaxGradientNorm(samples, 1e-4)
+	assert.True(t, EqualsWithMaxDelta(smoothNorm, math.Sqrt(2)/4, 1e-6))
+
+	steep := BasicCortex()
+	steep.Neurons[0].Inbound[0].Weights = []float64{1, 1}
+	steep.Neurons[0].ActivationFunction = &EncodableActivation{
+		Name:               "steep-sigmoid",
+		ActivationFunction: func(x float64) float64 { return Sigmoid(10 * x) },
+	}
+	steepNorm := steep.MaxGradientNorm(samples, 1e-4)
+	assert.True(t, steepNorm > smoothNorm)
+
+}
+
+func TestLinearNeurons(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+
+	// the xnor neurons all work well into the sigmoid's saturated region
+	assert.Equals(t, len(XnorCortex().LinearNeurons(examples, 1e-3)), 0)
+
+	// with tiny weights and no bias, the sigmoid only sees inputs near zero
+	cortex := BasicCortex()
+	cortex.Neurons[0].Inbound[0].Weights = []float64{0.01, 0.01}
+	linearNeurons := cortex.LinearNeurons(examples, 1e-3)
+	assert.Equals(t, len(linearNeurons), 1)
+	assert.Equals(t, linearNeurons[0].UUID, "neuron")
+
+	// an identity neuron is always linear
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[2].ActivationFunction = EncodableIdentity()
+	linearNeurons = xnorCortex.LinearNeurons(examples, 1e-3)
+	assert.Equals(t, len(linearNeurons), 1)
+	assert.Equals(t, linearNeurons[0].UUID, "output-neuron")
+
+}
+
+func TestIsDeterministic(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	assert.True(t, xnorCortex.IsDeterministic([][]float64{[]float64{0, 1}}, 20))
+
+	// the trials are run by the nodes, never by the direct evaluation that
+	// Activate uses for feed forward networks
+	assert.True(t, xnorCortex.plan == nil)
+	xnorCortex.Activate([][]float64{[]float64{0, 1}})
+	assert.True(t, xnorCortex.plan != nil)
+
+	// recurrent state is reset between trials, so a recurrent network is
+	// deterministic too
+	xnorCortex = XnorCortex()
+	outputNeuron := xnorCortex.Neurons[2]
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	outputNeuron.ConnectOutbound(hiddenNeuron1)
+	hiddenNeuron1.ConnectInboundWeighted(outputNeuron, []float64{1})
+	assert.True(t, xnorCortex.IsDeterministic([][]float64{[]float64{0, 1}}, 20))
+
+}
+
+func TestLayerwiseRelevance(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+
+	for _, sample := range XnorTrainingSamples() {
+
+		inputs := sample.SampleInputs
+		relevance := xnorCortex.LayerwiseRelevance(inputs, 0)
+		assert.Equals(t, len(relevance), 4)
+
+		outputs, _ := xnorCortex.Activate(inputs)
+		assert.True(t, EqualsWithMaxDelta(relevance["output-neuron"], outputs[0][0], 1e-9))
+
+		// relevance is conserved from one layer to the next
+		hiddenRelevance := relevance["hidden-neuron1"] + relevance["hidden-neuron2"]
+		assert.True(t, EqualsWithMaxDelta(hiddenRelevance, outputs[0][0], 1e-6))
+		// unless the inputs are all zero, and contribute nothing
+		if inputs[0][0] != 0 || inputs[0][1] != 0 {
+			assert.True(t, EqualsWithMaxDelta(relevance["sensor"], outputs[0][0], 1e-6))
+		}
+
+	}
+
+}
+
+func TestFindRedundantNeurons(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+	assert.Equals(t, len(XnorCortex().FindRedundantNeurons(examples, 1e-6)), 0)
+
+	// a split neuron is an exact copy of the original
+	xnorCortex := XnorCortex()
+	split := xnorCortex.SplitNeuron(xnorCortex.Neurons[1].NodeId)
+	redundant := xnorCortex.FindRedundantNeurons(examples, 1e-6)
+	assert.Equals(t, len(redundant), 1)
+	assert.Equals(t, len(redundant[0]), 2)
+	uuids := []string{redundant[0][0].UUID, redundant[0][1].UUID}
+	assert.True(t, uuids[0] == "hidden-neuron2" || uuids[1] == "hidden-neuron2")
+	assert.True(t, uuids[0] == split.NodeId.UUID || uuids[1] == split.NodeId.UUID)
+
+	// nudging the copy's weights apart makes it a different function
+	split.Inbound[0].Weights[0] += 1
+	assert.Equals(t, len(xnorCortex.FindRedundantNeurons(examples, 1e-6)), 0)
+
+}
+
+func TestNeuronCorrelation(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+	xnorCortex := XnorCortex()
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	hiddenNeuron2 := xnorCortex.Neurons[1]
+	split := xnorCortex.SplitNeuron(hiddenNeuron1.NodeId)
+
+	correlation := xnorCortex.NeuronCorrelation(hiddenNeuron1.NodeId, split.NodeId, examples)
+	assert.True(t, EqualsWithMaxDelta(correlation, 1.0, 1e-9))
+
+	// one only fires when both inputs are on, the other when both are off
+	correlation = xnorCortex.NeuronCorrelation(hiddenNeuron1.NodeId, hiddenNeuron2.NodeId, examples)
+	assert.True(t, correlation < 0)
+
+}
+
+func TestSignalMagnitudeByLayer(t *testing.T) {
+
+	// small weights and no biases, so the signal shrinks with every layer
+	cortex := NewFeedForwardCortex([]int{2, 2, 2, 2, 1}, EncodableTanh())
+	cortex.InitWeights(func(fromId, toId *NodeId, index int) float64 {
+		return 0.1
+	})
+	for _, neuron := range cortex.Neurons {
+		neuron.Bias = 0
+	}
+
+	magnitudes := cortex.SignalMagnitudeByLayer([][]float64{[]float64{1, 1}})
+	layerIndexes := cortex.NeuronLayerMap().Keys()
+	sort.Float64s(layerIndexes)
+	assert.Equals(t, len(magnitudes), len(layerIndexes))
+	for i := 1; i < len(layerIndexes); i++ {
+		assert.True(t, magnitudes[layerIndexes[i]] < magnitudes[layerIndexes[i-1]])
+	}
+	first, last := magnitudes[layerIndexes[0]], magnitudes[layerIndexes[len(layerIndexes)-1]]
+	assert.True(t, EqualsWithMaxDelta(first, math.Tanh(0.2), 1e-12))
+	assert.True(t, last < first/100)
+
+}
+
+func TestActivationDiversity(t *testing.T) {
+
+	// a hidden layer mixing sigmoid and tanh
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[1].ActivationFunction = EncodableTanh()
+
+	diversity := xnorCortex.ActivationDiversity()
+	assert.Equals(t, len(diversity), 2)
+	assert.Equals(t, diversity[0.25], []string{"sigmoid", "tanh"})
+	assert.Equals(t, diversity[0.35], []string{"sigmoid"})
+	assert.Equals(t, len(xnorCortex.NeuronLayerMap()[0.25]), 2)
+
+	expectedOutput := func(x1, x2 float64) float64 {
+		hidden1 := Sigmoid(20*x1 + 20*x2 - 30)
+		hidden2 := math.Tanh(-20*x1 - 20*x2 + 10)
+		return Sigmoid(20*hidden1 + 20*hidden2 - 10)
+	}
+
+	jsonBytes, err := json.Marshal(xnorCortex)
+	assert.True(t, err == nil)
+	decoded := &Cortex{}
+	assert.True(t, json.Unmarshal(jsonBytes, decoded) == nil)
+	assert.Equals(t, decoded.ActivationDiversity(), diversity)
+
+	for _, cortex := range []*Cortex{xnorCortex, decoded} {
+		for _, sample := range XnorTrainingSamples() {
+			inputs := sample.SampleInputs[0]
+			outputs, err := cortex.Activate(sample.SampleInputs)
+			assert.True(t, err == nil)
+			assert.True(t, EqualsWithMaxDelta(outputs[0][0], expectedOutput(inputs[0], inputs[1]), 1e-12))
+		}
+	}
+
+	var svg bytes.Buffer
+	xnorCortex.RenderSVG(&svg)
+	assert.True(t, svg.Len() > 0)
+
+}
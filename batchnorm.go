@@ -0,0 +1,77 @@
+package neurgo
+
+import (
+	"math"
+)
+
+// Keeps the normalized value finite when a neuron's weighted sum doesn't
+// vary across the batch.
+const BATCH_NORM_EPSILON = 1e-5
+
+// Running statistics of a neuron's weighted sum (bias included), which is
+// normalized to (x - Mean) / sqrt(Variance + BATCH_NORM_EPSILON).
+type BatchNormStats struct {
+	Mean     float64
+	Variance float64
+}
+
+func (stats *BatchNormStats) normalize(x float64) float64 {
+	return (x - stats.Mean) * stats.scale()
+}
+
+func (stats *BatchNormStats) scale() float64 {
+	return 1 / math.Sqrt(stats.Variance+BATCH_NORM_EPSILON)
+}
+
+func (neuron *Neuron) batchNormalize(weightedSum float64) float64 {
+	if neuron.BatchNorm == nil {
+		return weightedSum
+	}
+	return neuron.BatchNorm.normalize(weightedSum)
+}
+
+// Turn on batch normalization for every neuron in the layer, starting from
+// statistics that leave the weighted sums unchanged.
+func (cortex *Cortex) EnableBatchNorm(layerIndex float64) {
+	for _, neuron := range cortex.NeuronLayerMap()[layerIndex] {
+		if neuron.BatchNorm == nil {
+			neuron.BatchNorm = &BatchNormStats{Mean: 0, Variance: 1}
+		}
+	}
+}
+
+// Blend the batch's mean and variance of each normalized neuron's weighted
+// sum into its running statistics, weighting the old values by momentum.
+func (cortex *Cortex) UpdateBatchNorm(samples []*TrainingSample, momentum float64) error {
+
+	if err := cortex.checkStateless(); err != nil {
+		return err
+	}
+
+	outputs := make([]map[string][]float64, len(samples))
+	for i, sample := range samples {
+		outputs[i] = make(map[string][]float64)
+		inputs := cortex.scaleInputs(sample.SampleInputs)
+		for j, sensor := range cortex.Sensors {
+			outputs[i][sensor.NodeId.UUID] = inputs[j]
+		}
+	}
+
+	for _, neuron := range cortex.SortedNeurons() {
+		if neuron.BatchNorm != nil && len(samples) > 0 {
+			weightedSums := make([]float64, len(samples))
+			for i, _ := range samples {
+				weightedSums[i] = neuron.rawWeightedSum(outputs[i])
+			}
+			stats := neuron.BatchNorm
+			stats.Mean = momentum*stats.Mean + (1-momentum)*Average(weightedSums)
+			stats.Variance = momentum*stats.Variance + (1-momentum)*Variance(weightedSums)
+		}
+		for i, _ := range samples {
+			neuron.forwardPass(outputs[i])
+		}
+	}
+
+	return nil
+
+}
@@ -0,0 +1,57 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestBatchNorm(t *testing.T) {
+
+	rand.Seed(3)
+
+	samples := XnorTrainingSamples()
+	cortex := NewFeedForwardCortex([]int{2, 3, 2, 1}, EncodableSigmoid())
+	layerIndexes := cortex.NeuronLayerMap().Keys()
+	sort.Float64s(layerIndexes)
+	layerIndex := layerIndexes[0]
+	cortex.EnableBatchNorm(layerIndex)
+	assert.True(t, cortex.UpdateBatchNorm(samples, 0) == nil)
+
+	// run the batch through the network, and collect what goes into the
+	// activation function of each batch normalized neuron
+	var mutex sync.Mutex
+	weightedSums := make(map[string][]float64)
+	observer := &runObserver{
+		neuronFired: func(neuron *Neuron, weightedSum, output float64) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if neuron.NodeId.LayerIndex == layerIndex {
+				weightedSums[neuron.NodeId.UUID] = append(weightedSums[neuron.NodeId.UUID], weightedSum)
+			}
+		},
+	}
+	cortex.observe(observer, func() {
+		cortex.runSamples(samples)
+	})
+
+	assert.Equals(t, len(weightedSums), 3)
+	for _, sums := range weightedSums {
+		assert.Equals(t, len(sums), len(samples))
+		assert.True(t, EqualsWithMaxDelta(Average(sums), 0, 1e-9))
+		assert.True(t, EqualsWithMaxDelta(Variance(sums), 1, 1e-3))
+	}
+
+	// the statistics are saved with the cortex
+	cortexCopy := cortex.Copy()
+	assert.True(t, cortexCopy.OutputsMatch(cortex, samples, 1e-12))
+
+	// a momentum of 1 leaves them alone
+	stats := *cortex.Neurons[0].BatchNorm
+	cortex.Neurons[0].Bias += 1
+	assert.True(t, cortex.UpdateBatchNorm(samples, 1) == nil)
+	assert.Equals(t, *cortex.Neurons[0].BatchNorm, stats)
+
+}
@@ -0,0 +1,143 @@
+package neurgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Write out a standalone Go package whose Predict(inputs [][]float64) [][]float64
+// function computes the same outputs as Activate(), with the weights baked in.
+func (cortex *Cortex) GenerateGoCode(packageName string, w io.Writer) error {
+
+	if err := cortex.checkStateless(); err != nil {
+		return err
+	}
+
+	sensorIndexes := make(map[string]int)
+	for i, sensor := range cortex.Sensors {
+		sensorIndexes[sensor.NodeId.UUID] = i
+	}
+	neurons := cortex.SortedNeurons()
+	neuronIndexes := make(map[string]int)
+	for i, neuron := range neurons {
+		neuronIndexes[neuron.NodeId.UUID] = i
+	}
+
+	// the expression for element j of the output of the given node.  If the
+	// cortex has a Scaler, the sensor outputs are the scaled inputs, so the
+	// scaling is baked in along with the weights.
+	outputExpression := func(nodeId *NodeId, j int) (string, error) {
+		if i, ok := sensorIndexes[nodeId.UUID]; ok {
+			if cortex.Scaler == nil {
+				return fmt.Sprintf("inputs[%d][%d]", i, j), nil
+			}
+			width := cortex.Scaler.Max[i][j] - cortex.Scaler.Min[i][j]
+			if width == 0 {
+				return "0", nil
+			}
+			min, err := goFloatLiteral(cortex.Scaler.Min[i][j])
+			if err != nil {
+				return "", err
+			}
+			widthLiteral, err := goFloatLiteral(width)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("((inputs[%d][%d] - (%v)) / %v)", i, j, min, widthLiteral), nil
+		}
+		if i, ok := neuronIndexes[nodeId.UUID]; ok {
+			return fmt.Sprintf("n[%d]", i), nil
+		}
+		return "", fmt.Errorf("%v is not a sensor or neuron in this cortex", nodeId.UUID)
+	}
+
+	activationsUsed := make(map[string]bool)
+	body := &bytes.Buffer{}
+	fmt.Fprintf(body, "n := make([]float64, %d)\n", len(neurons))
+
+	for i, neuron := range neurons {
+		if neuron.BatchNorm != nil {
+			return fmt.Errorf("cannot generate code for batch normalized neuron: %v", neuron.NodeId.UUID)
+		}
+		fmt.Fprintf(body, "\n// %v\n", neuron.NodeId.UUID)
+		bias, err := goFloatLiteral(neuron.Bias)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(body, "n[%d] = %v\n", i, bias)
+		for _, inbound := range neuron.Inbound {
+			if inbound.Disabled {
+				continue
+			}
+			for j, weight := range inbound.Weights {
+				input, err := outputExpression(inbound.NodeId, j)
+				if err != nil {
+					return err
+				}
+				weightLiteral, err := goFloatLiteral(weight)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(body, "n[%d] += (%v) * %v\n", i, weightLiteral, input)
+			}
+		}
+		switch neuron.ActivationFunction.Name {
+		case "identity":
+		case "sigmoid", "tanh":
+			activationsUsed[neuron.ActivationFunction.Name] = true
+			fmt.Fprintf(body, "n[%d] = %v(n[%d])\n", i, neuron.ActivationFunction.Name, i)
+		default:
+			return fmt.Errorf("cannot generate code for activation function: %v", neuron.ActivationFunction.Name)
+		}
+	}
+
+	fmt.Fprintf(body, "\nreturn [][]float64{\n")
+	for _, actuator := range cortex.Actuators {
+		fmt.Fprintf(body, "[]float64{")
+		for _, inbound := range actuator.Inbound {
+			input, err := outputExpression(inbound.NodeId, 0)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(body, "%v, ", input)
+		}
+		fmt.Fprintf(body, "}, // %v\n", actuator.NodeId.UUID)
+	}
+	fmt.Fprintf(body, "}\n")
+
+	source := &bytes.Buffer{}
+	fmt.Fprintf(source, "// Code generated by neurgo from cortex %v. DO NOT EDIT.\n\n", cortex.NodeId.UUID)
+	fmt.Fprintf(source, "package %v\n\n", packageName)
+	if len(activationsUsed) > 0 {
+		fmt.Fprintf(source, "import \"math\"\n\n")
+	}
+	fmt.Fprintf(source, "// Takes one input vector per sensor, and returns one output vector per\n")
+	fmt.Fprintf(source, "// actuator.\n")
+	fmt.Fprintf(source, "func Predict(inputs [][]float64) [][]float64 {\n%v}\n", body.String())
+	if activationsUsed["sigmoid"] {
+		fmt.Fprintf(source, "\nfunc sigmoid(x float64) float64 {\nreturn 1.0 / (1.0 + math.Pow(math.E, -1.0*x))\n}\n")
+	}
+	if activationsUsed["tanh"] {
+		fmt.Fprintf(source, "\nfunc tanh(x float64) float64 {\nreturn math.Tanh(x)\n}\n")
+	}
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(formatted)
+	return err
+
+}
+
+// Format the value so that it parses back to exactly the same float64
+func goFloatLiteral(value float64) (string, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", fmt.Errorf("cannot write %v as a Go literal", value)
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64), nil
+}
@@ -0,0 +1,105 @@
+package neurgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/couchbaselabs/go.assert"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGoCode(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	source := &bytes.Buffer{}
+	err := xnorCortex.GenerateGoCode("main", source)
+	assert.True(t, err == nil)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "predict.go", source.Bytes(), 0)
+	assert.True(t, err == nil)
+
+	// recurrent networks aren't supported
+	outputNeuron := xnorCortex.Neurons[2]
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	outputNeuron.ConnectOutbound(hiddenNeuron1)
+	hiddenNeuron1.ConnectInboundWeighted(outputNeuron, []float64{1})
+	assert.True(t, xnorCortex.GenerateGoCode("main", &bytes.Buffer{}) != nil)
+
+	// the rest needs the go tool to build and run the generated code
+	goTool, err := exec.LookPath("go")
+	if err != nil || testing.Short() {
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "neurgo")
+	assert.True(t, err == nil)
+	defer os.RemoveAll(dir)
+
+	mainSource := `package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+func main() {
+	var inputs [][][]float64
+	json.NewDecoder(os.Stdin).Decode(&inputs)
+	outputs := make([][][]float64, 0)
+	for _, input := range inputs {
+		outputs = append(outputs, Predict(input))
+	}
+	json.NewEncoder(os.Stdout).Encode(outputs)
+}
+`
+	ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(mainSource), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "predict.go"), source.Bytes(), 0644)
+
+	examples := XnorTrainingSamples()
+	inputs := make([][][]float64, 0)
+	for _, sample := range examples {
+		inputs = append(inputs, sample.SampleInputs)
+	}
+	inputBytes, _ := json.Marshal(inputs)
+
+	cmd := exec.Command(goTool, "run", "main.go", "predict.go")
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(inputBytes)
+	outputBytes, err := cmd.Output()
+	assert.True(t, err == nil)
+
+	var outputs [][][]float64
+	assert.True(t, json.Unmarshal(outputBytes, &outputs) == nil)
+	assert.Equals(t, len(outputs), len(examples))
+	for i, sample := range examples {
+		expected, _ := XnorCortex().Activate(sample.SampleInputs)
+		assert.Equals(t, outputs[i], expected)
+	}
+
+}
+
+func TestGenerateGoCodeScaler(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	xnorCortex.Scaler = &FeatureScaler{
+		Min: [][]float64{[]float64{-10, 3}},
+		Max: [][]float64{[]float64{10, 3}},
+	}
+	source := &bytes.Buffer{}
+	err := xnorCortex.GenerateGoCode("main", source)
+	assert.True(t, err == nil)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "predict.go", source.Bytes(), 0)
+	assert.True(t, err == nil)
+
+	// the scaling is baked in, with constant features mapped to 0
+	assert.True(t, strings.Contains(source.String(), "((inputs[0][0] - (-10)) / 20)"))
+	assert.False(t, strings.Contains(source.String(), "inputs[0][1]"))
+
+}
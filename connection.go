@@ -7,8 +7,9 @@ import (
 )
 
 type InboundConnection struct {
-	NodeId  *NodeId
-	Weights []float64
+	NodeId   *NodeId
+	Weights  []float64
+	Disabled bool // if set, contributes nothing to the weighted sum (neurons only)
 }
 
 type OutboundConnection struct {
@@ -43,6 +44,7 @@ type weightedInput struct {
 	senderNodeUUID string
 	weights        []float64
 	inputs         []float64
+	disabled       bool
 }
 
 type UUIDToInboundConnection map[string]*InboundConnection
@@ -50,11 +52,13 @@ type UUIDToInboundConnection map[string]*InboundConnection
 func (connection *InboundConnection) MarshalJSON() ([]byte, error) {
 	return json.Marshal(
 		struct {
-			NodeId  *NodeId
-			Weights []float64
+			NodeId   *NodeId
+			Weights  []float64
+			Disabled bool
 		}{
-			NodeId:  connection.NodeId,
-			Weights: connection.Weights,
+			NodeId:   connection.NodeId,
+			Weights:  connection.Weights,
+			Disabled: connection.Disabled,
 		})
 }
 
@@ -75,6 +79,7 @@ func createEmptyWeightedInputs(inbound []*InboundConnection) []*weightedInput {
 			senderNodeUUID: inboundConnection.NodeId.UUID,
 			weights:        inboundConnection.Weights,
 			inputs:         nil,
+			disabled:       inboundConnection.Disabled,
 		}
 		weightedInputs[i] = weightedInput
 	}
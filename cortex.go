@@ -2,22 +2,51 @@ package neurgo
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/couchbaselabs/logg"
+	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
 	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const FITNESS_THRESHOLD = 1e8
 
 type Cortex struct {
-	NodeId    *NodeId
-	Sensors   []*Sensor
-	Neurons   []*Neuron
-	Actuators []*Actuator
-	SyncChan  chan *NodeId // TODO: rename to ActuatorBarrier
+	NodeId          *NodeId
+	Sensors         []*Sensor
+	Neurons         []*Neuron
+	Actuators       []*Actuator
+	Generation      int              // see SetLineage()
+	ParentIds       []string         // UUIDs of the cortexes this one was bred from
+	FeedForwardOnly bool             // see CheckConnection()
+	MaxFanIn        int              // see CheckConnection()
+	History         *TrainingHistory // if set, training records into it
+	Scaler          *FeatureScaler   // if set, inputs are scaled with it
+	Profiling       bool             // see NeuronTimings()
+	InferenceMode   bool             // skip logging and observers, for speed
+	SyncChan        chan *NodeId     // TODO: rename to ActuatorBarrier
+	liveness        *nodeLiveness
+	observer        *runObserver
+	syncCount       int64              // see FiringStatus()
+	gradientNorms   map[string]float64 // see LastGradientMagnitudes()
+	plan            *inferencePlan     // see ActivateInto()
+	ranDirectly     bool               // see FiringStatus()
+}
+
+// Hooks called as signals move through the network; either can be nil, and
+// both must be safe to call from multiple goroutines.
+type runObserver struct {
+	neuronFired func(neuron *Neuron, weightedSum, output float64)
+	messageSent func(senderId, receiverId *NodeId, inputs []float64)
 }
 
 type ActuatorBarrier map[*NodeId]bool // TODO: fixme!! totally broken
@@ -29,31 +58,65 @@ func (cortex *Cortex) Run() {
 
 	cortex.checkRunnable()
 
-	// TODO: merge slices, create Runnable() interface
-	// and make into single loop
+	cortex.liveness = nil
+	cortex.launchNodes()
+}
 
-	for _, sensor := range cortex.Sensors {
-		go sensor.Run()
+// Like Run(), but waits until every node is up (and primed), and supervises
+// the node goroutines so that failures are reported by HealthCheck().
+func (cortex *Cortex) Start() error {
+
+	cortex.Init()
+
+	if cortex.SyncChan == nil {
+		return errors.New("cortex.SyncChan is nil")
 	}
-	for _, neuron := range cortex.Neurons {
-		go neuron.Run()
+	if validated := cortex.Validate(); !validated {
+		return errors.New("cortex.Validate failed")
 	}
-	for _, actuator := range cortex.Actuators {
-		go actuator.Run()
+
+	numNodes := len(cortex.Sensors) + len(cortex.Neurons) + len(cortex.Actuators)
+	cortex.liveness = newNodeLiveness(numNodes)
+
+	cortex.launchNodes()
+
+	// if any nodes died while starting up, report them
+	cortex.liveness.waitUntilRunning()
+	return cortex.liveness.check()
+}
+
+// Report any node goroutines started by Start() which have exited, panicked,
+// or been stuck sending for longer than STUCK_SEND_TIMEOUT.
+func (cortex *Cortex) HealthCheck() error {
+	if cortex.liveness == nil {
+		return errors.New("cortex is not running, or was not launched via Start()")
 	}
+	return cortex.liveness.check()
 }
 
+// Stop all of the nodes.  Nodes which are known to have died already (see
+// HealthCheck()) are skipped, since nothing is listening for them to close.
 func (cortex *Cortex) Shutdown() {
+	if cortex.liveness != nil {
+		cortex.liveness.stop()
+	}
 	for _, sensor := range cortex.Sensors {
-		sensor.Shutdown()
+		if !cortex.nodeHasExited(sensor.NodeId) {
+			sensor.Shutdown()
+		}
 	}
 	for _, neuron := range cortex.Neurons {
-		neuron.Shutdown()
+		if !cortex.nodeHasExited(neuron.NodeId) {
+			neuron.Shutdown()
+		}
 	}
 	for _, actuator := range cortex.Actuators {
-		actuator.Shutdown()
+		if !cortex.nodeHasExited(actuator.NodeId) {
+			actuator.Shutdown()
+		}
 	}
 	cortex.SyncChan = nil
+	cortex.liveness = nil
 }
 
 // Initialize/re-initialize the cortex.
@@ -156,6 +219,86 @@ func (cortex *Cortex) AllNodeIds() []*NodeId {
 	return availableNodeIds
 }
 
+// The number of neurons in each layer, keyed by layer index
+func (cortex *Cortex) LayerWidths() map[float64]int {
+	layerWidths := make(map[float64]int)
+	for layerIndex, neurons := range cortex.NeuronLayerMap() {
+		layerWidths[layerIndex] = len(neurons)
+	}
+	return layerWidths
+}
+
+// The width of the narrowest layer divided by that of the widest.
+func (cortex *Cortex) LayerBalance() float64 {
+	minWidth, maxWidth := 0, 0
+	for _, width := range cortex.LayerWidths() {
+		if minWidth == 0 || width < minWidth {
+			minWidth = width
+		}
+		if width > maxWidth {
+			maxWidth = width
+		}
+	}
+	if maxWidth == 0 {
+		return 1
+	}
+	return float64(minWidth) / float64(maxWidth)
+}
+
+// The number of connections in the network, counting each one once from
+// the receiving end, whether or not it's disabled.
+func (cortex *Cortex) ConnectionCount() int {
+	count := 0
+	for _, neuron := range cortex.Neurons {
+		count += len(neuron.Inbound)
+	}
+	for _, actuator := range cortex.Actuators {
+		count += len(actuator.Inbound)
+	}
+	return count
+}
+
+// The number of connections which haven't been disabled.
+func (cortex *Cortex) ActiveConnectionCount() int {
+	count := 0
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			if !inbound.Disabled {
+				count += 1
+			}
+		}
+	}
+	for _, actuator := range cortex.Actuators {
+		count += len(actuator.Inbound)
+	}
+	return count
+}
+
+// The neurons ordered by layer, then by UUID.
+func (cortex *Cortex) SortedNeurons() []*Neuron {
+	neurons := make([]*Neuron, len(cortex.Neurons))
+	copy(neurons, cortex.Neurons)
+	sort.Sort(neuronsByLayer(neurons))
+	return neurons
+}
+
+type neuronsByLayer []*Neuron
+
+func (neurons neuronsByLayer) Len() int {
+	return len(neurons)
+}
+
+func (neurons neuronsByLayer) Swap(i, j int) {
+	neurons[i], neurons[j] = neurons[j], neurons[i]
+}
+
+func (neurons neuronsByLayer) Less(i, j int) bool {
+	if neurons[i].NodeId.LayerIndex != neurons[j].NodeId.LayerIndex {
+		return neurons[i].NodeId.LayerIndex < neurons[j].NodeId.LayerIndex
+	}
+	return neurons[i].NodeId.UUID < neurons[j].NodeId.UUID
+}
+
 func (cortex *Cortex) NeuronLayerMap() LayerToNeuronMap {
 	layerToNeuronMap := make(LayerToNeuronMap)
 	for _, neuron := range cortex.Neurons {
@@ -213,18 +356,104 @@ func (cortex *Cortex) InitOutboundConnections() {
 func (cortex *Cortex) MarshalJSON() ([]byte, error) {
 	return json.Marshal(
 		struct {
-			NodeId    *NodeId
-			Sensors   []*Sensor
-			Neurons   []*Neuron
-			Actuators []*Actuator
+			NodeId          *NodeId
+			Sensors         []*Sensor
+			Neurons         []*Neuron
+			Actuators       []*Actuator
+			Generation      int
+			ParentIds       []string
+			FeedForwardOnly bool
+			MaxFanIn        int
+			History         *TrainingHistory
+			Scaler          *FeatureScaler
 		}{
-			NodeId:    cortex.NodeId,
-			Sensors:   cortex.Sensors,
-			Neurons:   cortex.Neurons,
-			Actuators: cortex.Actuators,
+			NodeId:          cortex.NodeId,
+			Sensors:         cortex.Sensors,
+			Neurons:         cortex.Neurons,
+			Actuators:       cortex.Actuators,
+			Generation:      cortex.Generation,
+			ParentIds:       cortex.ParentIds,
+			FeedForwardOnly: cortex.FeedForwardOnly,
+			MaxFanIn:        cortex.MaxFanIn,
+			History:         cortex.History,
+			Scaler:          cortex.Scaler,
 		})
 }
 
+// Decode a cortex written by MarshalJSON.  It still needs to be Init()'d.
+func (cortex *Cortex) UnmarshalJSON(bytes []byte) error {
+
+	// a type without the UnmarshalJSON method, to get the default decoding
+	type encodedCortex Cortex
+	if err := json.Unmarshal(bytes, (*encodedCortex)(cortex)); err != nil {
+		return err
+	}
+	cortex.LinkNodesToCortex()
+
+	return nil
+}
+
+// Record that this cortex was bred from the given parents.
+func (cortex *Cortex) SetLineage(parents ...*Cortex) {
+	generation := 0
+	parentIds := make([]string, 0)
+	for _, parent := range parents {
+		if parent.Generation+1 > generation {
+			generation = parent.Generation + 1
+		}
+		parentIds = append(parentIds, parent.NodeId.UUID)
+	}
+	cortex.Generation = generation
+	cortex.ParentIds = parentIds
+}
+
+// The number of bytes in the cortex's JSON representation, for estimating
+// how much memory or disk a population of them will need.
+func (cortex *Cortex) SerializedSize() int {
+	jsonBytes, err := json.Marshal(cortex)
+	if err != nil {
+		log.Panicf("Could not marshal cortex: %v", err)
+	}
+	return len(jsonBytes)
+}
+
+// The gzipped JSON representation, for compact checkpoints.
+func (cortex *Cortex) MarshalBinary() ([]byte, error) {
+
+	jsonBytes, err := json.Marshal(cortex)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(jsonBytes); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+
+}
+
+// Replace the contents of the cortex with one serialized by MarshalBinary
+func (cortex *Cortex) UnmarshalBinary(data []byte) error {
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	jsonBytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	*cortex = Cortex{}
+	return json.Unmarshal(jsonBytes, cortex)
+
+}
+
 func (cortex *Cortex) MarshalJSONToFile(filename string) error {
 
 	json, err := json.MarshalIndent(cortex, "", "    ")
@@ -236,6 +465,95 @@ func (cortex *Cortex) MarshalJSONToFile(filename string) error {
 	return nil
 }
 
+// Write the cortex to the given path as JSON, replacing the file if it
+// already exists.  See LoadCortexFromFile.
+func (cortex *Cortex) SaveToFile(path string) error {
+	jsonBytes, err := json.MarshalIndent(cortex, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, jsonBytes, 0666)
+}
+
+// Read a cortex written by SaveToFile, checking that it is complete and
+// initializing it, so that it's ready to run.
+func LoadCortexFromFile(path string) (*Cortex, error) {
+
+	jsonBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cortex := &Cortex{}
+	if err := json.Unmarshal(jsonBytes, cortex); err != nil {
+		return nil, fmt.Errorf("could not parse cortex in %v: %v", path, err)
+	}
+	if err := cortex.checkComplete(); err != nil {
+		return nil, fmt.Errorf("invalid cortex in %v: %v", path, err)
+	}
+
+	cortex.Init()
+	if !cortex.Validate() {
+		return nil, fmt.Errorf("invalid cortex in %v: cortex.Validate failed", path)
+	}
+	return cortex, nil
+
+}
+
+// Make sure every node has a NodeId, every neuron has an activation
+// function, and every connection leads to a node in this cortex.
+func (cortex *Cortex) checkComplete() error {
+
+	uuids := make(map[string]bool)
+	for _, nodeId := range cortex.AllNodeIds() {
+		if nodeId == nil {
+			return errors.New("found a node with no NodeId")
+		}
+		uuids[nodeId.UUID] = true
+	}
+
+	checkConnection := func(nodeId *NodeId, connectedId *NodeId) error {
+		if connectedId == nil {
+			return fmt.Errorf("%v has a connection with no NodeId", nodeId.UUID)
+		}
+		if !uuids[connectedId.UUID] {
+			return fmt.Errorf("%v is connected to unknown node %v", nodeId.UUID, connectedId.UUID)
+		}
+		return nil
+	}
+
+	for _, sensor := range cortex.Sensors {
+		for _, outbound := range sensor.Outbound {
+			if err := checkConnection(sensor.NodeId, outbound.NodeId); err != nil {
+				return err
+			}
+		}
+	}
+	for _, neuron := range cortex.Neurons {
+		if neuron.ActivationFunction == nil {
+			return fmt.Errorf("neuron %v has no activation function", neuron.NodeId.UUID)
+		}
+		for _, inbound := range neuron.Inbound {
+			if err := checkConnection(neuron.NodeId, inbound.NodeId); err != nil {
+				return err
+			}
+		}
+		for _, outbound := range neuron.Outbound {
+			if err := checkConnection(neuron.NodeId, outbound.NodeId); err != nil {
+				return err
+			}
+		}
+	}
+	for _, actuator := range cortex.Actuators {
+		for _, inbound := range actuator.Inbound {
+			if err := checkConnection(actuator.NodeId, inbound.NodeId); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+
+}
+
 func (cortex *Cortex) String() string {
 	return JsonString(cortex)
 }
@@ -302,6 +620,10 @@ func (cortex *Cortex) Copy() *Cortex {
 		actuatorCopy.ActuatorFunction = actuator.ActuatorFunction
 	}
 
+	// fresh channels, so the copy can be run (or rewired) independently of
+	// the original
+	cortexCopy.Init()
+
 	return cortexCopy
 
 }
@@ -318,6 +640,128 @@ func (cortex *Cortex) shutdownOutboundConnections() {
 
 }
 
+func (cortex *Cortex) launchNodes() {
+
+	atomic.StoreInt64(&cortex.syncCount, 0)
+	cortex.ranDirectly = false
+
+	// TODO: merge slices, create Runnable() interface
+	// and make into single loop
+
+	for _, sensor := range cortex.Sensors {
+		cortex.launch(sensor.NodeId, sensor.Run)
+	}
+	for _, neuron := range cortex.Neurons {
+		cortex.launch(neuron.NodeId, neuron.Run)
+	}
+	for _, actuator := range cortex.Actuators {
+		cortex.launch(actuator.NodeId, actuator.Run)
+	}
+}
+
+func (cortex *Cortex) launch(nodeId *NodeId, run func()) {
+	liveness := cortex.liveness
+	if liveness == nil {
+		go run()
+		return
+	}
+	go func() {
+		defer func() {
+			liveness.nodeExited(nodeId, recover())
+		}()
+		run()
+	}()
+}
+
+// Called by each node once it is running.  Safe to call on a nil cortex.
+func (cortex *Cortex) nodeRunning(nodeId *NodeId) {
+	if cortex != nil && cortex.liveness != nil {
+		cortex.liveness.nodeRunning(nodeId)
+	}
+}
+
+// Has the node's goroutine returned?  Only known for a cortex launched via
+// Start().
+func (cortex *Cortex) nodeHasExited(nodeId *NodeId) bool {
+	return cortex.liveness != nil && cortex.liveness.hasExited(nodeId)
+}
+
+// Called by each node before it blocks sending to receiver, and with an empty
+// receiver once the message has gone.  Safe to call on a nil cortex.
+func (cortex *Cortex) sending(senderId *NodeId, receiver string) {
+	if cortex != nil && cortex.liveness != nil {
+		cortex.liveness.sending(senderId, receiver)
+	}
+}
+
+// Called by each neuron every time it fires.  Safe to call on a nil
+// cortex, or one with no observer.
+func (cortex *Cortex) neuronFired(neuron *Neuron, weightedSum, output float64) {
+	if cortex != nil && cortex.observer != nil && cortex.observer.neuronFired != nil {
+		cortex.observer.neuronFired(neuron, weightedSum, output)
+	}
+}
+
+// Called by sensors and neurons just before sending each message.  Safe to
+// call on a nil cortex, or one with no observer.
+func (cortex *Cortex) messageSent(senderId, receiverId *NodeId, inputs []float64) {
+	if cortex != nil && cortex.observer != nil && cortex.observer.messageSent != nil {
+		cortex.observer.messageSent(senderId, receiverId, inputs)
+	}
+}
+
+// Install the observer on the cortex for the duration of run.
+func (cortex *Cortex) observe(observer *runObserver, run func()) {
+	cortex.observer = observer
+	defer func() {
+		cortex.observer = nil
+	}()
+	run()
+}
+
+// Whether nodes launched now should run in InferenceMode.  Safe to call on a
+// nil cortex.
+func (cortex *Cortex) runInInferenceMode() bool {
+	return cortex != nil && cortex.InferenceMode && cortex.observer == nil
+}
+
+// Whether each neuron has fired for the current pass, to help track down a
+// stalled network.  After a direct pass (see ActivateInto) all have fired.
+func (cortex *Cortex) FiringStatus() map[string]bool {
+
+	if cortex.ranDirectly {
+		firingStatus := make(map[string]bool)
+		for _, neuron := range cortex.Neurons {
+			firingStatus[neuron.NodeId.UUID] = true
+		}
+		return firingStatus
+	}
+
+	pass := atomic.LoadInt64(&cortex.syncCount)
+	fireCounts := make(map[string]int64)
+	for _, neuron := range cortex.Neurons {
+		fireCounts[neuron.NodeId.UUID] = atomic.LoadInt64(&neuron.fireCount)
+		if fireCounts[neuron.NodeId.UUID] > pass {
+			pass = fireCounts[neuron.NodeId.UUID]
+		}
+	}
+
+	firingStatus := make(map[string]bool)
+	for uuid, fireCount := range fireCounts {
+		firingStatus[uuid] = fireCount >= pass
+	}
+	return firingStatus
+}
+
+// The total time each neuron has spent computing its output while Profiling.
+func (cortex *Cortex) NeuronTimings() map[string]time.Duration {
+	timings := make(map[string]time.Duration)
+	for _, neuron := range cortex.Neurons {
+		timings[neuron.NodeId.UUID] = time.Duration(atomic.LoadInt64(&neuron.computeNanos))
+	}
+	return timings
+}
+
 func (cortex *Cortex) nodeIdToDataMsg() nodeIdToDataMsgMap {
 	nodeIdToDataMsg := make(nodeIdToDataMsgMap)
 	for _, neuron := range cortex.Neurons {
@@ -346,6 +790,234 @@ func (cortex *Cortex) Verify(samples []*TrainingSample) bool {
 }
 
 func (cortex *Cortex) Fitness(samples []*TrainingSample) float64 {
+	return cortex.FitnessWith(samples, SumOfSquaresError)
+}
+
+// Same as Fitness, but with the error on each sample measured by errorFn.
+func (cortex *Cortex) FitnessWith(samples []*TrainingSample, errorFn func(expected, actual []float64) float64) float64 {
+
+	errorAccumulated := float64(0)
+	cortex.evaluateSamples(samples, errorFn, nil, func(error float64) {
+		errorAccumulated += error
+	})
+
+	// calculate fitness
+	fitness := float64(1) / errorAccumulated
+
+	return fitness
+
+}
+
+// Same as Fitness, less lambda times the sum of the absolute weights.
+func (cortex *Cortex) FitnessWithL1(samples []*TrainingSample, lambda float64) float64 {
+	l1 := 0.0
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			if inbound.Disabled {
+				continue
+			}
+			for _, weight := range inbound.Weights {
+				l1 += math.Abs(weight)
+			}
+		}
+	}
+	return cortex.Fitness(samples) - lambda*l1
+}
+
+// How much Fitness would change if the weightIndex'th weight on the
+// connection from fromId to toId were set to newValue.
+func (cortex *Cortex) FitnessDelta(fromId, toId *NodeId, weightIndex int, newValue float64, samples []*TrainingSample) float64 {
+
+	target := cortex.FindNeuron(toId)
+	if target == nil {
+		log.Panicf("No neuron found with id: %v", toId.UUID)
+	}
+	inbound, ok := target.InboundUUIDMap()[fromId.UUID]
+	if !ok {
+		log.Panicf("No connection from %v to %v", fromId.UUID, toId.UUID)
+	}
+	if weightIndex < 0 || weightIndex >= len(inbound.Weights) {
+		log.Panicf("Weight index %d out of range for connection from %v to %v", weightIndex, fromId.UUID, toId.UUID)
+	}
+	oldValue := inbound.Weights[weightIndex]
+
+	if err := cortex.checkStateless(); err != nil {
+		before := cortex.Fitness(samples)
+		inbound.Weights[weightIndex] = newValue
+		after := cortex.Fitness(samples)
+		inbound.Weights[weightIndex] = oldValue
+		return after - before
+	}
+
+	// SortedNeurons puts every neuron after the ones feeding it, so one pass
+	// is enough to find everything downstream of the target
+	neurons := cortex.SortedNeurons()
+	affected := map[string]bool{toId.UUID: true}
+	downstream := make([]*Neuron, 0)
+	for _, neuron := range neurons {
+		for _, neuronInbound := range neuron.Inbound {
+			if affected[neuronInbound.NodeId.UUID] && !neuronInbound.Disabled {
+				affected[neuron.NodeId.UUID] = true
+			}
+		}
+		if affected[neuron.NodeId.UUID] {
+			downstream = append(downstream, neuron)
+		}
+	}
+
+	errorBefore, errorAfter := 0.0, 0.0
+	for _, sample := range samples {
+		outputs, _ := cortex.forwardPass(neurons, sample.SampleInputs)
+		errorBefore += cortex.forwardPassError(outputs, sample)
+		inbound.Weights[weightIndex] = newValue
+		for _, neuron := range downstream {
+			neuron.forwardPass(outputs)
+		}
+		inbound.Weights[weightIndex] = oldValue
+		errorAfter += cortex.forwardPassError(outputs, sample)
+	}
+
+	return float64(1)/errorAfter - float64(1)/errorBefore
+
+}
+
+// The sum of squares error of the outputs from forwardPass against the
+// sample's expected outputs.
+func (cortex *Cortex) forwardPassError(outputs map[string][]float64, sample *TrainingSample) float64 {
+	errorAccumulated := 0.0
+	for i, actuator := range cortex.Actuators {
+		actual := make([]float64, len(actuator.Inbound))
+		for j, inbound := range actuator.Inbound {
+			actual[j] = outputs[inbound.NodeId.UUID][0]
+		}
+		errorAccumulated += SumOfSquaresError(sample.ExpectedOutputs[i], actual)
+	}
+	return errorAccumulated
+}
+
+// The fitness of a single sample, along with the squared error per output.
+func (cortex *Cortex) FitnessForSample(sample *TrainingSample) (float64, []float64) {
+
+	outputs := cortex.runSamples([]*TrainingSample{sample})[0]
+
+	outputErrors := make([]float64, 0)
+	errorAccumulated := float64(0)
+	for i, actual := range outputs {
+		expected := sample.ExpectedOutputs[i]
+		for j, _ := range actual {
+			error := SumOfSquaresError(expected[j:j+1], actual[j:j+1])
+			outputErrors = append(outputErrors, error)
+			errorAccumulated += error
+		}
+	}
+
+	return float64(1) / errorAccumulated, outputErrors
+
+}
+
+// Same as Fitness, but also return the outputs for each sample.
+func (cortex *Cortex) EvaluateWithPredictions(samples []*TrainingSample) (float64, [][][]float64) {
+
+	predictions := cortex.runSamples(samples)
+
+	errorAccumulated := float64(0)
+	for i, sampleOutputs := range predictions {
+		for j, actual := range sampleOutputs {
+			errorAccumulated += SumOfSquaresError(samples[i].ExpectedOutputs[j], actual)
+		}
+	}
+
+	return float64(1) / errorAccumulated, predictions
+
+}
+
+// Same as Fitness, but with gaussian noise added to every input value.
+func (cortex *Cortex) EvaluateWithInputNoise(samples []*TrainingSample, noiseStdDev float64, rng *rand.Rand) float64 {
+
+	noisySamples := make([]*TrainingSample, len(samples))
+	for i, sample := range samples {
+		noisyInputs := make([][]float64, len(sample.SampleInputs))
+		for j, inputs := range sample.SampleInputs {
+			noisyInputs[j] = make([]float64, len(inputs))
+			for k, input := range inputs {
+				noisyInputs[j][k] = input + rng.NormFloat64()*noiseStdDev
+			}
+		}
+		noisySamples[i] = &TrainingSample{
+			SampleInputs:    noisyInputs,
+			ExpectedOutputs: sample.ExpectedOutputs,
+		}
+	}
+
+	return cortex.Fitness(noisySamples)
+
+}
+
+// The total sum of squares error over a sequence of passes through the single
+// sensor, carrying recurrent state between passes unless resetBetween is set.
+func (cortex *Cortex) EvaluateSequence(inputs [][]float64, expected [][]float64, resetBetween bool) float64 {
+
+	if len(cortex.Sensors) != 1 {
+		log.Panicf("Must have exactly one sensor")
+	}
+	if len(cortex.Actuators) != 1 {
+		log.Panicf("Must have exactly one actuator")
+	}
+	if len(inputs) != len(expected) {
+		log.Panicf("Got %d inputs but %d expected outputs", len(inputs), len(expected))
+	}
+
+	passes := make([][][]float64, len(inputs))
+	for i, input := range inputs {
+		passes[i] = [][]float64{input}
+	}
+
+	outputs := make([][][]float64, 0)
+	if resetBetween {
+		for _, pass := range passes {
+			outputs = append(outputs, cortex.runPasses([][][]float64{pass})...)
+		}
+	} else {
+		outputs = cortex.runPasses(passes)
+	}
+
+	errorAccumulated := float64(0)
+	for i, passOutputs := range outputs {
+		errorAccumulated += SumOfSquaresError(expected[i], passOutputs[0])
+	}
+	return errorAccumulated
+
+}
+
+// Like Fitness, but emits the fitness so far after each sample.  Calling stop
+// cancels the evaluation and waits until the cortex can be used again.
+func (cortex *Cortex) FitnessStream(samples []*TrainingSample) (fitnesses <-chan float64, stop func()) {
+
+	fitnessChan := make(chan float64, len(samples))
+	done := make(chan bool)
+	finished := make(chan bool)
+
+	go func() {
+		errorAccumulated := float64(0)
+		cortex.evaluateSamples(samples, SumOfSquaresError, done, func(error float64) {
+			errorAccumulated += error
+			fitnessChan <- float64(1) / errorAccumulated
+		})
+		close(fitnessChan)
+		close(finished)
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(done) })
+		<-finished
+	}
+	return fitnessChan, stop
+
+}
+
+// Call errorFunc with each sample's error, stopping early if done is closed.
+func (cortex *Cortex) evaluateSamples(samples []*TrainingSample, errorFn func(expected, actual []float64) float64, done <-chan bool, errorFunc func(error float64)) {
 
 	cortex.Init()
 	cortex.LinkNodesToCortex()
@@ -354,8 +1026,6 @@ func (cortex *Cortex) Fitness(samples []*TrainingSample) float64 {
 		log.Panicf("Cortex did not Validate()")
 	}
 
-	errorAccumulated := float64(0)
-
 	// assumes there is only one sensor and one actuator
 	// (to support more, this method will require more coding)
 	if len(cortex.Sensors) != 1 {
@@ -367,20 +1037,22 @@ func (cortex *Cortex) Fitness(samples []*TrainingSample) float64 {
 
 	// install function to sensor which will stream training samples
 	sensor := cortex.Sensors[0]
+	savedSensorFunc := sensor.SensorFunction
 	sensorFunc := func(syncCounter int) []float64 {
 		sampleX := samples[syncCounter]
-		return sampleX.SampleInputs[0]
+		return cortex.scaleInputs(sampleX.SampleInputs)[0]
 	}
 	sensor.SensorFunction = sensorFunc
 
 	// install function to actuator which will collect outputs
 	actuator := cortex.Actuators[0]
+	savedActuatorFunc := actuator.ActuatorFunction
 	numTimesFuncCalled := 0
 	actuatorFunc := func(outputs []float64) {
 		expected := samples[numTimesFuncCalled].ExpectedOutputs[0]
-		error := SumOfSquaresError(expected, outputs)
+		error := errorFn(expected, outputs)
 		logg.LogTo("DEBUG", "expected: %v actual: %v error: %v", expected, outputs, error)
-		errorAccumulated += error
+		errorFunc(error)
 		numTimesFuncCalled += 1
 		// cortex.SyncChan <- actuator.NodeId <-- moved to actuator itself
 	}
@@ -388,18 +1060,171 @@ func (cortex *Cortex) Fitness(samples []*TrainingSample) float64 {
 
 	go cortex.Run()
 
+evaluation:
 	for _ = range samples {
+		select {
+		case <-done:
+			break evaluation
+		default:
+		}
 		cortex.SyncSensors()
 		cortex.SyncActuators()
 	}
 
 	cortex.Shutdown()
 
-	// calculate fitness
-	fitness := float64(1) / errorAccumulated
+	sensor.SensorFunction = savedSensorFunc
+	actuator.ActuatorFunction = savedActuatorFunc
 
-	return fitness
+}
+
+// Run each set of inputs through the nodes, one pass per entry, and return
+// what each actuator received on every pass.
+func (cortex *Cortex) runPasses(inputs [][][]float64) [][][]float64 {
+
+	scaledInputs := make([][][]float64, len(inputs))
+	for i, passInputs := range inputs {
+		scaledInputs[i] = cortex.scaleInputs(passInputs)
+	}
+
+	cortex.Init()
+	cortex.LinkNodesToCortex()
+
+	if ok := cortex.Validate(); !ok {
+		log.Panicf("Cortex did not Validate()")
+	}
+
+	sensorFuncs := make([]SensorFunction, len(cortex.Sensors))
+	for i, sensor := range cortex.Sensors {
+		sensorFuncs[i] = sensor.SensorFunction
+		sensorIndex := i
+		sensor.SensorFunction = func(syncCounter int) []float64 {
+			return scaledInputs[syncCounter][sensorIndex]
+		}
+	}
+
+	outputs := make([][][]float64, len(inputs))
+	for i := range outputs {
+		outputs[i] = make([][]float64, len(cortex.Actuators))
+	}
+
+	actuatorFuncs := make([]ActuatorFunction, len(cortex.Actuators))
+	for i, actuator := range cortex.Actuators {
+		actuatorFuncs[i] = actuator.ActuatorFunction
+		actuatorIndex := i
+		numTimesFuncCalled := 0
+		actuator.ActuatorFunction = func(actuatorOutputs []float64) {
+			outputs[numTimesFuncCalled][actuatorIndex] = actuatorOutputs
+			numTimesFuncCalled += 1
+		}
+	}
+
+	go cortex.Run()
+
+	for _ = range inputs {
+		cortex.SyncSensors()
+		cortex.SyncActuators()
+	}
+
+	cortex.Shutdown()
+
+	for i, sensor := range cortex.Sensors {
+		sensor.SensorFunction = sensorFuncs[i]
+	}
+	for i, actuator := range cortex.Actuators {
+		actuator.ActuatorFunction = actuatorFuncs[i]
+	}
+
+	return outputs
+
+}
+
+// Feed one input vector per sensor through the network and return the
+// output vector gathered by each actuator.
+func (cortex *Cortex) Activate(inputs [][]float64) ([][]float64, error) {
+	outputs := make([][]float64, len(cortex.Actuators))
+	for i, actuator := range cortex.Actuators {
+		outputs[i] = make([]float64, actuator.VectorLength)
+	}
+	if err := cortex.ActivateInto(inputs, outputs); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// Activate the network and read each actuator's output as a set of class
+// scores, returning the index of the highest scoring class per actuator.
+func (cortex *Cortex) Predict(inputs [][]float64) ([]int, error) {
+	outputs, err := cortex.Activate(inputs)
+	if err != nil {
+		return nil, err
+	}
+	classes := make([]int, len(outputs))
+	for i, actuatorOutputs := range outputs {
+		classes[i] = ArgMax(actuatorOutputs)
+	}
+	return classes, nil
+}
+
+// Same as Activate, but copies the outputs into the caller's buffers.
+// Stateless networks (see checkStateless) are evaluated directly.
+func (cortex *Cortex) ActivateInto(inputs [][]float64, outputs [][]float64) error {
+
+	if len(inputs) != len(cortex.Sensors) {
+		return fmt.Errorf("got %d input vectors for %d sensors", len(inputs), len(cortex.Sensors))
+	}
+	for i, sensor := range cortex.Sensors {
+		if len(inputs[i]) != sensor.VectorLength {
+			return fmt.Errorf("input vector %v has length %d, expected %d", inputs[i], len(inputs[i]), sensor.VectorLength)
+		}
+	}
+	if len(outputs) != len(cortex.Actuators) {
+		return fmt.Errorf("got %d output buffers for %d actuators", len(outputs), len(cortex.Actuators))
+	}
+	for i, actuator := range cortex.Actuators {
+		if len(outputs[i]) != actuator.VectorLength {
+			return fmt.Errorf("output buffer %d has length %d, expected %d", i, len(outputs[i]), actuator.VectorLength)
+		}
+	}
+
+	if cortex.activateDirectly(inputs, outputs) {
+		return nil
+	}
+
+	results := cortex.runPasses([][][]float64{inputs})
+	for i, actuatorOutputs := range results[0] {
+		copy(outputs[i], actuatorOutputs)
+	}
+	return nil
+
+}
+
+// Evaluate the network with its inferencePlan, or return false if it has to
+// be run by its nodes.
+func (cortex *Cortex) activateDirectly(inputs [][]float64, outputs [][]float64) bool {
+	if cortex.observer != nil || cortex.Profiling {
+		return false
+	}
+	if cortex.Scaler != nil && !cortex.Scaler.fits(cortex.Sensors) {
+		return false
+	}
+	if cortex.plan == nil || !cortex.plan.current(cortex) {
+		cortex.plan = newInferencePlan(cortex)
+		if cortex.plan == nil {
+			return false
+		}
+	}
+	cortex.plan.run(cortex.Scaler, inputs, outputs)
+	cortex.ranDirectly = true
+	return true
+}
 
+func (cortex *Cortex) runSamples(samples []*TrainingSample) [][][]float64 {
+	inputs := make([][][]float64, len(samples))
+	for i, sample := range samples {
+		inputs[i] = sample.SampleInputs
+	}
+	return cortex.runPasses(inputs)
 }
 
 func (cortex *Cortex) FindSensor(nodeId *NodeId) *Sensor {
@@ -460,6 +1285,7 @@ func (cortex *Cortex) FindInboundConnector(nodeId *NodeId) InboundConnector {
 }
 
 func (cortex *Cortex) SyncSensors() {
+	atomic.AddInt64(&cortex.syncCount, 1)
 	for _, sensor := range cortex.Sensors {
 		select {
 		case sensor.SyncChan <- true:
@@ -515,6 +1341,47 @@ func (cortex *Cortex) Validate() bool {
 	return true
 }
 
+// Check that no weight, bias or all-zero-input output is NaN or infinite.
+func (cortex *Cortex) ValidateNumerics() error {
+
+	isBad := func(x float64) bool {
+		return math.IsNaN(x) || math.IsInf(x, 0)
+	}
+
+	for _, neuron := range cortex.Neurons {
+		if isBad(neuron.Bias) {
+			return fmt.Errorf("neuron %v has bias %v", neuron.NodeId.UUID, neuron.Bias)
+		}
+		for _, inbound := range neuron.Inbound {
+			for _, weight := range inbound.Weights {
+				if isBad(weight) {
+					return fmt.Errorf("neuron %v has weight %v on its connection from %v",
+						neuron.NodeId.UUID, weight, inbound.NodeId.UUID)
+				}
+			}
+		}
+	}
+
+	inputs := make([][]float64, len(cortex.Sensors))
+	for i, sensor := range cortex.Sensors {
+		inputs[i] = make([]float64, sensor.VectorLength)
+	}
+	outputs, err := cortex.Activate(inputs)
+	if err != nil {
+		return err
+	}
+	for i, actuator := range cortex.Actuators {
+		for _, output := range outputs[i] {
+			if isBad(output) {
+				return fmt.Errorf("actuator %v received %v on a zero input pass",
+					actuator.NodeId.UUID, output)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (cortex *Cortex) Repair() {
 	cortex.LinkNodesToCortex()
 }
@@ -569,7 +1436,6 @@ func NewCortexFromJSONFile(filename string) (cortex *Cortex, err error) {
 		logg.Warn("Unable to parse file: %v.  Error: %v", filename, err)
 		return
 	}
-	cortex.LinkNodesToCortex()
 	return
 }
 
@@ -583,9 +1449,6 @@ func NewCortexFromJSONSBytes(jsonBytes []byte) (cortex *Cortex, err error) {
 
 	// deserialize json into new cortex
 	err = json.Unmarshal(jsonBytes, cortex)
-	if err == nil {
-		cortex.LinkNodesToCortex()
-	}
 
 	return
 
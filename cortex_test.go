@@ -2,11 +2,18 @@ package neurgo
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/couchbaselabs/go.assert"
 	"github.com/couchbaselabs/logg"
 	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func init() {
@@ -62,6 +69,79 @@ func TestCortexCopy(t *testing.T) {
 
 }
 
+func TestCortexCopyIndependent(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	xnorCortexCopy := xnorCortex.Copy()
+
+	// structurally the same
+	assert.Equals(t, JsonString(xnorCortexCopy), JsonString(xnorCortex))
+
+	// but with its own nodes and channels, wired to each other
+	neurons := xnorCortexCopy.NeuronUUIDMap()
+	for i, neuron := range xnorCortex.Neurons {
+		neuronCopy := xnorCortexCopy.Neurons[i]
+		assert.True(t, neuronCopy != neuron)
+		assert.True(t, neuronCopy.DataChan != nil)
+		assert.True(t, neuronCopy.DataChan != neuron.DataChan)
+		assert.True(t, neuronCopy.Closing != neuron.Closing)
+		for _, outbound := range neuronCopy.Outbound {
+			if target, ok := neurons[outbound.NodeId.UUID]; ok {
+				assert.True(t, outbound.DataChan == target.DataChan)
+			}
+		}
+	}
+	sensorOutbound := xnorCortexCopy.Sensors[0].Outbound[0]
+	assert.True(t, sensorOutbound.DataChan == neurons[sensorOutbound.NodeId.UUID].DataChan)
+
+	// mutating the copy leaves the original alone, and it can be rewired
+	// straight away
+	neurons["output-neuron"].Bias = 100
+	neurons["output-neuron"].ConnectOutbound(neurons["output-neuron"])
+	neurons["output-neuron"].ConnectInboundWeighted(neurons["output-neuron"], []float64{1})
+	assert.Equals(t, xnorCortex.Neurons[2].Bias, -10.0)
+	assert.Equals(t, len(xnorCortex.Neurons[2].Outbound), 1)
+
+	// both run on their own
+	examples := XnorTrainingSamples()
+	assert.True(t, xnorCortex.Fitness(examples) >= FITNESS_THRESHOLD)
+	assert.True(t, xnorCortexCopy.Fitness(examples) < FITNESS_THRESHOLD)
+
+}
+
+func TestCortexSerializationPreservesParameters(t *testing.T) {
+
+	// values which are easy to round off when printed
+	xnorCortex := XnorCortex()
+	parameters := []float64{
+		math.Pi / 3, 0.1 + 0.2, -1.0 / 7,
+		math.SmallestNonzeroFloat64, math.MaxFloat64, -2.5e-17,
+		math.Nextafter(1, 2), math.E * 1e10, -math.Sqrt2,
+	}
+	assert.True(t, xnorCortex.SetParameters(parameters) == nil)
+
+	sameBits := func(cortex *Cortex) bool {
+		for i, parameter := range cortex.GetParameters() {
+			if math.Float64bits(parameter) != math.Float64bits(parameters[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	assert.True(t, sameBits(xnorCortex.Copy()))
+
+	dir, err := ioutil.TempDir("", "neurgo")
+	assert.True(t, err == nil)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "cortex.json")
+	assert.True(t, xnorCortex.MarshalJSONToFile(filename) == nil)
+	loaded, err := NewCortexFromJSONFile(filename)
+	assert.True(t, err == nil)
+	assert.True(t, sameBits(loaded))
+
+}
+
 func TestCortexJsonMarshal(t *testing.T) {
 	xnorCortex := XnorCortex()
 	xnorCortex.MarshalJSONToFile("/tmp/output.json")
@@ -88,6 +168,94 @@ func TestCortexJsonUnmarshal(t *testing.T) {
 
 }
 
+func TestCortexJsonRoundTrip(t *testing.T) {
+
+	feedForward := XnorCortex()
+
+	// the same network with a recurrent connection from the output neuron
+	// back to itself
+	recurrent := XnorCortex()
+	outputNeuron := recurrent.Neurons[2]
+	outputNeuron.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(outputNeuron, []float64{0.5})
+
+	for _, original := range []*Cortex{feedForward, recurrent} {
+
+		jsonBytes, err := json.Marshal(original)
+		assert.True(t, err == nil)
+		decoded := &Cortex{}
+		assert.True(t, json.Unmarshal(jsonBytes, decoded) == nil)
+
+		for _, nodeId := range decoded.AllNodeIds() {
+			if nodeId.NodeType == CORTEX {
+				continue
+			}
+			assert.True(t, decoded.FindConnector(nodeId) != nil || decoded.FindActuator(nodeId) != nil)
+		}
+		for _, neuron := range decoded.Neurons {
+			assert.True(t, neuron.Cortex == decoded)
+		}
+		assert.Equals(t, len(decoded.Neurons[2].Inbound), len(original.Neurons[2].Inbound))
+
+		decoded.Init()
+		for _, sample := range XnorTrainingSamples() {
+			expected, err := original.Activate(sample.SampleInputs)
+			assert.True(t, err == nil)
+			actual, err := decoded.Activate(sample.SampleInputs)
+			assert.True(t, err == nil)
+			assert.Equals(t, actual, expected)
+		}
+
+	}
+
+	err := json.Unmarshal([]byte(`{"Neurons": 7}`), &Cortex{})
+	assert.True(t, err != nil)
+
+}
+
+func TestCortexSerializedSize(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	size := xnorCortex.SerializedSize()
+	jsonBytes, _ := json.Marshal(xnorCortex)
+	assert.Equals(t, size, len(jsonBytes))
+
+	sensor := xnorCortex.Sensors[0]
+	outputNeuron := xnorCortex.Neurons[2]
+	sensor.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(sensor, []float64{1, 1})
+	assert.True(t, xnorCortex.SerializedSize() > size)
+
+}
+
+func TestCortexBinaryMarshal(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	xnorCortex.Generation = 2
+	xnorCortex.Neurons[0].Metadata = map[string]string{"origin": "test"}
+
+	binaryBytes, err := xnorCortex.MarshalBinary()
+	assert.True(t, err == nil)
+	jsonBytes, err := json.Marshal(xnorCortex)
+	assert.True(t, err == nil)
+	assert.True(t, len(binaryBytes) < len(jsonBytes))
+
+	decoded := &Cortex{}
+	err = decoded.UnmarshalBinary(binaryBytes)
+	assert.True(t, err == nil)
+	decodedJsonBytes, err := json.Marshal(decoded)
+	assert.True(t, err == nil)
+	assert.Equals(t, string(decodedJsonBytes), string(jsonBytes))
+
+	// it comes back linked up and ready to run
+	assert.True(t, decoded.Validate())
+	assert.True(t, decoded.Verify(XnorTrainingSamples()))
+
+	err = decoded.UnmarshalBinary([]byte("not gzipped"))
+	assert.True(t, err != nil)
+
+}
+
 func TestCortexInit(t *testing.T) {
 
 	jsonBytes := []byte(exampleCortexJson())
@@ -174,6 +342,483 @@ func TestCortexFitness(t *testing.T) {
 
 }
 
+func TestCortexFitnessWith(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	examples := XnorTrainingSamples()
+
+	absoluteError := func(expected, actual []float64) float64 {
+		total := 0.0
+		for i, value := range expected {
+			total += math.Abs(actual[i] - value)
+		}
+		return total
+	}
+
+	sumOfSquaresFitness := xnorCortex.FitnessWith(examples, SumOfSquaresError)
+	assert.Equals(t, sumOfSquaresFitness, xnorCortex.Fitness(examples))
+
+	// every error is well under 1, so squaring them makes them smaller
+	absoluteFitness := xnorCortex.FitnessWith(examples, absoluteError)
+	assert.True(t, absoluteFitness > 0)
+	assert.True(t, absoluteFitness < sumOfSquaresFitness)
+
+}
+
+func TestCortexFitnessStream(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+	fitness := XnorCortex().Fitness(examples)
+
+	fitnesses := make([]float64, 0)
+	stream, _ := XnorCortex().FitnessStream(examples)
+	for fitnessSoFar := range stream {
+		fitnesses = append(fitnesses, fitnessSoFar)
+	}
+	assert.Equals(t, len(fitnesses), len(examples))
+
+	// error only accumulates, so fitness can only go down
+	for i := 1; i < len(fitnesses); i++ {
+		assert.True(t, fitnesses[i] <= fitnesses[i-1])
+	}
+	assert.Equals(t, fitnesses[len(fitnesses)-1], fitness)
+
+	// stopping after the first value closes the stream, and leaves the
+	// cortex ready to be evaluated again straight away
+	xnorCortex := XnorCortex()
+	stream, stop := xnorCortex.FitnessStream(examples)
+	<-stream
+	stop()
+	for _ = range stream {
+	}
+	assert.Equals(t, xnorCortex.Fitness(examples), fitness)
+	stop()
+
+}
+
+func TestCortexFitnessDelta(t *testing.T) {
+
+	// weaken one of the hidden neurons so that the fitness is modest, and
+	// the delta isn't swamped by rounding
+	xnorCortex := XnorCortex()
+	hiddenNeuron1 := xnorCortex.NeuronUUIDMap()["hidden-neuron1"]
+	hiddenNeuron1.Inbound[0].Weights = []float64{5, 5}
+
+	samples := XnorTrainingSamples()
+	fromId := hiddenNeuron1.NodeId
+	toId := xnorCortex.NeuronUUIDMap()["output-neuron"].NodeId
+
+	before := xnorCortex.Fitness(samples)
+	delta := xnorCortex.FitnessDelta(fromId, toId, 0, 10, samples)
+
+	// the cortex itself is unchanged
+	assert.Equals(t, xnorCortex.NeuronUUIDMap()["output-neuron"].Inbound[0].Weights[0], 20.0)
+
+	modified := xnorCortex.Copy()
+	modified.NeuronUUIDMap()["output-neuron"].Inbound[0].Weights[0] = 10
+	after := modified.Fitness(samples)
+
+	assert.True(t, delta != 0)
+	assert.True(t, math.Abs(delta-(after-before)) < 1e-9*math.Abs(after))
+
+}
+
+func TestCortexFitnessForSample(t *testing.T) {
+
+	// nudge the output neuron so the errors aren't vanishingly small
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[2].Bias = -18
+	examples := XnorTrainingSamples()
+
+	inverseSum := float64(0)
+	for _, example := range examples {
+		fitness, outputErrors := xnorCortex.FitnessForSample(example)
+		assert.Equals(t, len(outputErrors), 1)
+		assert.True(t, EqualsWithMaxDelta(fitness, 1/outputErrors[0], 1e-9))
+		inverseSum += 1 / fitness
+	}
+
+	fitness := xnorCortex.Fitness(examples)
+	assert.True(t, EqualsWithMaxDelta(1/inverseSum, fitness, fitness*1e-9))
+
+}
+
+func TestCortexFitnessWithL1(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[2].Bias = -18
+	fitness := xnorCortex.Fitness(examples)
+	assert.Equals(t, xnorCortex.FitnessWithL1(examples, 0), fitness)
+	assert.True(t, EqualsWithMaxDelta(xnorCortex.FitnessWithL1(examples, 0.5), fitness-60, 1e-6))
+
+	// an extra hidden neuron which the output neuron ignores changes
+	// nothing but the number of weights
+	denser := xnorCortex.Copy()
+	denser.Init()
+	sensor := denser.Sensors[0]
+	outputNeuron := denser.Neurons[2]
+	extraNeuron := &Neuron{
+		ActivationFunction: EncodableSigmoid(),
+		NodeId:             NewNeuronId("extra-neuron", 0.25),
+	}
+	extraNeuron.Init()
+	sensor.ConnectOutbound(extraNeuron)
+	extraNeuron.ConnectInboundWeighted(sensor, []float64{5, -5})
+	extraNeuron.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(extraNeuron, []float64{0})
+	denser.SetNeurons(append(denser.Neurons, extraNeuron))
+	assert.Equals(t, denser.Fitness(examples), fitness)
+
+	assert.True(t, xnorCortex.FitnessWithL1(examples, 1e-3) > denser.FitnessWithL1(examples, 1e-3))
+
+	// the weights on a disabled connection aren't penalized
+	extraNeuron.Inbound[0].Disabled = true
+	assert.True(t, EqualsWithMaxDelta(denser.FitnessWithL1(examples, 1e-3), xnorCortex.FitnessWithL1(examples, 1e-3), 1e-9))
+
+}
+
+func TestCortexEvaluateWithPredictions(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[2].Bias = -18
+	examples := XnorTrainingSamples()
+
+	fitness, predictions := xnorCortex.EvaluateWithPredictions(examples)
+	assert.Equals(t, fitness, xnorCortex.Fitness(examples))
+	assert.Equals(t, len(predictions), len(examples))
+	for i, example := range examples {
+		outputs, err := xnorCortex.Activate(example.SampleInputs)
+		assert.True(t, err == nil)
+		assert.Equals(t, predictions[i], outputs)
+	}
+
+}
+
+func TestCortexEvaluateWithInputNoise(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+	fitness := XnorCortex().Fitness(examples)
+
+	rng := rand.New(rand.NewSource(42))
+	assert.Equals(t, XnorCortex().EvaluateWithInputNoise(examples, 0, rng), fitness)
+
+	noisyFitness := XnorCortex().EvaluateWithInputNoise(examples, 0.5, rand.New(rand.NewSource(42)))
+	assert.True(t, noisyFitness != fitness)
+
+	// the same seed gives the same noise
+	again := XnorCortex().EvaluateWithInputNoise(examples, 0.5, rand.New(rand.NewSource(42)))
+	assert.Equals(t, again, noisyFitness)
+
+	// and the samples are untouched
+	assert.Equals(t, examples[0].SampleInputs, XnorTrainingSamples()[0].SampleInputs)
+
+}
+
+// A network whose output on each pass is its input from the previous pass,
+// scaled by the given weight.  The delay neuron sits in a later layer than
+// the output neuron, so its connection back to it is recurrent.
+func echoCortex(weight float64) *Cortex {
+
+	sensor := &Sensor{
+		NodeId:       NewSensorId("sensor", 0.0),
+		VectorLength: 1,
+	}
+	sensor.Init()
+	outputNeuron := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("output-neuron", 0.25),
+	}
+	outputNeuron.Init()
+	delayNeuron := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("delay-neuron", 0.5),
+	}
+	delayNeuron.Init()
+	actuator := &Actuator{
+		NodeId:       NewActuatorId("actuator", 0.75),
+		VectorLength: 1,
+	}
+	actuator.Init()
+
+	// the output neuron ignores the current input, but still receives it
+	// so that it fires once per pass
+	sensor.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(sensor, []float64{0})
+	sensor.ConnectOutbound(delayNeuron)
+	delayNeuron.ConnectInboundWeighted(sensor, []float64{1})
+	delayNeuron.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(delayNeuron, []float64{weight})
+	outputNeuron.ConnectOutbound(actuator)
+	actuator.ConnectInbound(outputNeuron)
+
+	cortex := &Cortex{
+		NodeId: NewCortexId("echo-cortex"),
+	}
+	cortex.SetSensors([]*Sensor{sensor})
+	cortex.SetNeurons([]*Neuron{outputNeuron, delayNeuron})
+	cortex.SetActuators([]*Actuator{actuator})
+	return cortex
+
+}
+
+func TestCortexEvaluateSequence(t *testing.T) {
+
+	inputs := [][]float64{
+		[]float64{0.5},
+		[]float64{-1},
+		[]float64{2},
+		[]float64{0.25},
+	}
+	expected := [][]float64{
+		[]float64{0},
+		[]float64{0.5},
+		[]float64{-1},
+		[]float64{2},
+	}
+
+	// echoing the previous input is exactly right
+	assert.Equals(t, echoCortex(1).EvaluateSequence(inputs, expected, false), 0.0)
+
+	// moving the weight towards the echo reduces the error
+	untrained := echoCortex(0).EvaluateSequence(inputs, expected, false)
+	partlyTrained := echoCortex(0.5).EvaluateSequence(inputs, expected, false)
+	assert.Equals(t, untrained, 0.25+1+4)
+	assert.True(t, partlyTrained < untrained)
+
+	// without state carried between passes, it can only output zero
+	assert.Equals(t, echoCortex(1).EvaluateSequence(inputs, expected, true), untrained)
+
+}
+
+func TestCortexPredict(t *testing.T) {
+
+	// classifies the sign of its input: class 0 for positive, 1 for negative
+	sensor := &Sensor{
+		NodeId:       NewSensorId("sensor", 0.0),
+		VectorLength: 1,
+	}
+	sensor.Init()
+	positive := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("positive", 0.25),
+	}
+	positive.Init()
+	negative := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("negative", 0.25),
+	}
+	negative.Init()
+	actuator := &Actuator{
+		NodeId:       NewActuatorId("actuator", 0.5),
+		VectorLength: 2,
+	}
+	actuator.Init()
+
+	sensor.ConnectOutbound(positive)
+	positive.ConnectInboundWeighted(sensor, []float64{1})
+	sensor.ConnectOutbound(negative)
+	negative.ConnectInboundWeighted(sensor, []float64{-1})
+	positive.ConnectOutbound(actuator)
+	actuator.ConnectInbound(positive)
+	negative.ConnectOutbound(actuator)
+	actuator.ConnectInbound(negative)
+
+	cortex := &Cortex{
+		NodeId: NewCortexId("classifier"),
+	}
+	cortex.SetSensors([]*Sensor{sensor})
+	cortex.SetNeurons([]*Neuron{positive, negative})
+	cortex.SetActuators([]*Actuator{actuator})
+
+	classes, err := cortex.Predict([][]float64{[]float64{3}})
+	assert.True(t, err == nil)
+	assert.Equals(t, classes, []int{0})
+
+	classes, err = cortex.Predict([][]float64{[]float64{-0.5}})
+	assert.True(t, err == nil)
+	assert.Equals(t, classes, []int{1})
+
+	// a tie goes to the lowest class
+	classes, err = cortex.Predict([][]float64{[]float64{0}})
+	assert.True(t, err == nil)
+	assert.Equals(t, classes, []int{0})
+
+	_, err = cortex.Predict([][]float64{})
+	assert.True(t, err != nil)
+
+}
+
+func TestCortexFiringStatus(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	sensor := xnorCortex.Sensors[0]
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+
+	go xnorCortex.Run()
+
+	// only feed one of the hidden neurons, so the output neuron stalls
+	// waiting on the other one
+	hiddenNeuron1.DataChan <- &DataMessage{
+		SenderId: sensor.NodeId,
+		Inputs:   []float64{1, 1},
+	}
+
+	// until hidden-neuron1 fires, no neuron is behind
+	var firingStatus map[string]bool
+	for i := 0; i < 100; i++ {
+		firingStatus = xnorCortex.FiringStatus()
+		if !firingStatus["hidden-neuron2"] {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equals(t, firingStatus, map[string]bool{
+		"hidden-neuron1": true,
+		"hidden-neuron2": false,
+		"output-neuron":  false,
+	})
+
+	xnorCortex.Shutdown()
+
+	// a pass made without the nodes can't stall, and leaves the fire counts,
+	// which only the nodes keep, alone
+	_, err := xnorCortex.Activate([][]float64{[]float64{0, 1}})
+	assert.True(t, err == nil)
+	for _, fired := range xnorCortex.FiringStatus() {
+		assert.True(t, fired)
+	}
+	assert.Equals(t, xnorCortex.NeuronUUIDMap()["hidden-neuron2"].fireCount, int64(0))
+
+	// as does a pass made by the nodes, once it's complete
+	xnorCortex.runPasses([][][]float64{[][]float64{[]float64{0, 1}}})
+	for _, fired := range xnorCortex.FiringStatus() {
+		assert.True(t, fired)
+	}
+	assert.Equals(t, xnorCortex.NeuronUUIDMap()["hidden-neuron2"].fireCount, int64(1))
+
+}
+
+func TestCortexValidateNumerics(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	assert.True(t, xnorCortex.ValidateNumerics() == nil)
+
+	xnorCortex.Neurons[1].Inbound[0].Weights[1] = math.NaN()
+	err := xnorCortex.ValidateNumerics()
+	assert.True(t, err != nil)
+	assert.True(t, strings.Contains(err.Error(), "hidden-neuron2"))
+
+	xnorCortex = XnorCortex()
+	xnorCortex.Neurons[2].Bias = math.Inf(1)
+	err = xnorCortex.ValidateNumerics()
+	assert.True(t, err != nil)
+	assert.True(t, strings.Contains(err.Error(), "output-neuron"))
+
+	// parameters are fine, but the output isn't
+	xnorCortex = XnorCortex()
+	xnorCortex.Neurons[2].ActivationFunction = &EncodableActivation{
+		Name:               "nan",
+		ActivationFunction: func(x float64) float64 { return math.NaN() },
+	}
+	err = xnorCortex.ValidateNumerics()
+	assert.True(t, err != nil)
+	assert.True(t, strings.Contains(err.Error(), "actuator"))
+
+}
+
+func TestLayerWidths(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	assert.Equals(t, xnorCortex.LayerWidths(), map[float64]int{0.25: 2, 0.35: 1})
+	assert.Equals(t, xnorCortex.LayerBalance(), 0.5)
+
+	assert.Equals(t, BasicCortex().LayerBalance(), 1.0)
+
+}
+
+func TestActiveConnectionCount(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	assert.Equals(t, xnorCortex.ConnectionCount(), 5)
+	assert.Equals(t, xnorCortex.ActiveConnectionCount(), 5)
+
+	outputNeuron := xnorCortex.NeuronUUIDMap()["output-neuron"]
+	outputNeuron.Inbound[1].Disabled = true
+	assert.Equals(t, xnorCortex.ConnectionCount(), 5)
+	assert.Equals(t, xnorCortex.ActiveConnectionCount(), 4)
+
+	// survives a round trip through json
+	cortexCopy := xnorCortex.Copy()
+	assert.Equals(t, cortexCopy.ActiveConnectionCount(), 4)
+
+	// actuators ignore Disabled, so their connections always count
+	actuator := cortexCopy.Actuators[0]
+	actuator.Inbound[0].Disabled = true
+	assert.Equals(t, cortexCopy.ActiveConnectionCount(), 4)
+
+	// with hidden-neuron2 cut off, the output no longer depends on it, so
+	// the network no longer computes xnor
+	assert.True(t, xnorCortex.Fitness(XnorTrainingSamples()) < 1e8)
+
+}
+
+func TestSortedNeurons(t *testing.T) {
+
+	sortedUUIDs := func(cortex *Cortex) []string {
+		uuids := make([]string, 0)
+		for _, neuron := range cortex.SortedNeurons() {
+			uuids = append(uuids, neuron.NodeId.UUID)
+		}
+		return uuids
+	}
+	expected := []string{"hidden-neuron1", "hidden-neuron2", "output-neuron"}
+
+	xnorCortex := XnorCortex()
+	assert.Equals(t, sortedUUIDs(xnorCortex), expected)
+	assert.Equals(t, sortedUUIDs(xnorCortex), expected)
+
+	// adding the neurons in a different order makes no difference
+	neurons := xnorCortex.Neurons
+	xnorCortex.Neurons = []*Neuron{neurons[2], neurons[1], neurons[0]}
+	assert.Equals(t, sortedUUIDs(xnorCortex), expected)
+
+	// and the cortex's own slice is left alone
+	assert.Equals(t, xnorCortex.Neurons[0].NodeId.UUID, "output-neuron")
+
+}
+
+func TestCortexLineage(t *testing.T) {
+
+	mother := XnorCortex()
+	mother.Generation = 3
+	father := XnorCortex()
+	father.NodeId = NewCortexId("father")
+	father.Generation = 5
+
+	// breed a child by copying one parent and giving it a new identity
+	child := mother.Copy()
+	child.NodeId = NewCortexId(NewUuid())
+	child.SetLineage(mother, father)
+	assert.Equals(t, child.Generation, 6)
+	assert.Equals(t, child.ParentIds, []string{mother.NodeId.UUID, "father"})
+
+	grandchild := child.Copy()
+	grandchild.SetLineage(child)
+	assert.Equals(t, grandchild.Generation, 7)
+	assert.Equals(t, grandchild.ParentIds, []string{child.NodeId.UUID})
+
+	// the lineage is serialized with the cortex
+	jsonBytes, err := json.Marshal(child)
+	assert.True(t, err == nil)
+	decoded := &Cortex{}
+	err = json.Unmarshal(jsonBytes, decoded)
+	assert.True(t, err == nil)
+	assert.Equals(t, decoded.Generation, 6)
+	assert.Equals(t, decoded.ParentIds, child.ParentIds)
+
+}
+
 func TestNeuronLayerMap(t *testing.T) {
 	xnorCortex := XnorCortex()
 	layerToNeuronMap := xnorCortex.NeuronLayerMap()
@@ -198,8 +843,287 @@ func TestMarshalJsonToFile(t *testing.T) {
 
 }
 
+func TestSaveAndLoadCortexFromFile(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "neurgo")
+	assert.True(t, err == nil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "xnor.json")
+
+	// save a bigger cortex first, to make sure the file gets replaced
+	annotated := XnorCortex()
+	annotated.Neurons[0].Metadata = map[string]string{"notes": strings.Repeat("x", 1000)}
+	assert.True(t, annotated.SaveToFile(path) == nil)
+
+	xnorCortex := XnorCortex()
+	assert.True(t, xnorCortex.SaveToFile(path) == nil)
+	loaded, err := LoadCortexFromFile(path)
+	assert.True(t, err == nil)
+	assert.Equals(t, loaded.GetParameters(), xnorCortex.GetParameters())
+	assert.True(t, loaded.Neurons[0].Metadata == nil)
+	assert.True(t, loaded.Verify(XnorTrainingSamples()))
+
+	_, err = LoadCortexFromFile(filepath.Join(dir, "missing.json"))
+	assert.True(t, err != nil)
+
+	// a connection to a neuron which isn't there
+	dangling := XnorCortex()
+	dangling.Neurons = dangling.Neurons[1:]
+	assert.True(t, dangling.SaveToFile(path) == nil)
+	_, err = LoadCortexFromFile(path)
+	assert.True(t, err != nil)
+	assert.True(t, strings.Contains(err.Error(), "unknown node"))
+
+	noActivation := XnorCortex()
+	noActivation.Neurons[0].ActivationFunction = nil
+	assert.True(t, noActivation.SaveToFile(path) == nil)
+	_, err = LoadCortexFromFile(path)
+	assert.True(t, err != nil)
+	assert.True(t, strings.Contains(err.Error(), "no activation function"))
+
+}
+
 func TestNewCortexFromJSONString(t *testing.T) {
 	cortex, err := NewCortexFromJSONString(exampleCortexJson())
 	assert.True(t, err == nil)
 	assert.True(t, cortex != nil)
 }
+
+func TestCortexStartShutdown(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+
+	xnorCortex.Sensors[0].SensorFunction = func(syncCounter int) []float64 {
+		return []float64{1, 1}
+	}
+	var output []float64
+	xnorCortex.Actuators[0].ActuatorFunction = func(outputs []float64) {
+		output = outputs
+	}
+
+	err := xnorCortex.Start()
+	assert.True(t, err == nil)
+
+	xnorCortex.SyncSensors()
+	xnorCortex.SyncActuators()
+
+	xnorCortex.Shutdown()
+
+	assert.True(t, vectorEqualsWithMaxDelta(output, []float64{1}, 0.01))
+
+}
+
+func TestCortexStartInvalid(t *testing.T) {
+	xnorCortex := XnorCortexUntrained()
+	err := xnorCortex.Start()
+	assert.True(t, err != nil)
+}
+
+func TestCortexHealthCheck(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	assert.True(t, xnorCortex.HealthCheck() != nil)
+
+	err := xnorCortex.Start()
+	assert.True(t, err == nil)
+	assert.True(t, xnorCortex.HealthCheck() == nil)
+
+	// kill one of the neurons behind the cortex's back
+	killedNeuron := xnorCortex.Neurons[0]
+	closingResponse := make(chan bool)
+	killedNeuron.Closing <- closingResponse
+	<-closingResponse
+
+	healthy := true
+	for i := 0; i < 100; i++ {
+		if err = xnorCortex.HealthCheck(); err != nil {
+			healthy = false
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.False(t, healthy)
+	assert.True(t, strings.Contains(err.Error(), killedNeuron.NodeId.UUID))
+
+	// the dead neuron is skipped
+	xnorCortex.Shutdown()
+
+}
+
+func TestCortexHealthCheckStuck(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	err := xnorCortex.Start()
+	assert.True(t, err == nil)
+	xnorCortex.liveness.stuckAfter = time.Millisecond * 50
+
+	// once the first hidden neuron is gone, the sensor blocks forever
+	// trying to send it the next input
+	killedNeuron := xnorCortex.Neurons[0]
+	closingResponse := make(chan bool)
+	killedNeuron.Closing <- closingResponse
+	<-closingResponse
+	xnorCortex.SyncSensors()
+
+	stuck := false
+	for i := 0; i < 100; i++ {
+		err = xnorCortex.HealthCheck()
+		if err != nil && strings.Contains(err.Error(), "blocked sending") {
+			stuck = true
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.True(t, stuck)
+	expected := fmt.Sprintf("sensor has been blocked sending to %v", killedNeuron.NodeId.UUID)
+	assert.True(t, strings.Contains(err.Error(), expected))
+
+	// the stuck sensor can still be shut down
+	xnorCortex.Shutdown()
+
+}
+
+func TestCortexActivateInto(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+
+	outputs := [][]float64{make([]float64, 1)}
+	outputBuffer := outputs[0]
+
+	for _, example := range XnorTrainingSamples() {
+		err := xnorCortex.ActivateInto(example.SampleInputs, outputs)
+		assert.True(t, err == nil)
+		expected := example.ExpectedOutputs[0]
+		assert.True(t, vectorEqualsWithMaxDelta(outputBuffer, expected, 0.01))
+	}
+
+	// once the network has been activated, it doesn't allocate
+	inputs := [][]float64{[]float64{1, 0}}
+	allocs := testing.AllocsPerRun(100, func() {
+		xnorCortex.ActivateInto(inputs, outputs)
+	})
+	assert.Equals(t, allocs, float64(0))
+
+	// not even when scaling the inputs
+	xnorCortex.Scaler = &FeatureScaler{
+		Min: [][]float64{[]float64{0, 0}},
+		Max: [][]float64{[]float64{1, 1}},
+	}
+	allocs = testing.AllocsPerRun(100, func() {
+		xnorCortex.ActivateInto(inputs, outputs)
+	})
+	assert.Equals(t, allocs, float64(0))
+	xnorCortex.Scaler = nil
+
+	// changes to the weights are picked up straight away, and changes to
+	// the topology too
+	xnorCortex.ActivateInto(inputs, outputs)
+	assert.True(t, outputBuffer[0] < 0.01)
+	outputNeuron := xnorCortex.Neurons[2]
+	outputNeuron.Bias = 10
+	xnorCortex.ActivateInto(inputs, outputs)
+	assert.True(t, outputBuffer[0] > 0.99)
+	sensor := xnorCortex.Sensors[0]
+	sensor.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(sensor, []float64{-100, -100})
+	xnorCortex.ActivateInto(inputs, outputs)
+	assert.True(t, outputBuffer[0] < 0.01)
+
+	// the same as running the nodes
+	for _, example := range XnorTrainingSamples() {
+		xnorCortex.ActivateInto(example.SampleInputs, outputs)
+		expected := xnorCortex.runPasses([][][]float64{example.SampleInputs})[0]
+		assert.Equals(t, outputs, expected)
+	}
+
+	// the buffers must be the right shape
+	err := xnorCortex.ActivateInto([][]float64{[]float64{1, 1}}, [][]float64{})
+	assert.True(t, err != nil)
+	err = xnorCortex.ActivateInto([][]float64{[]float64{1}}, outputs)
+	assert.True(t, err != nil)
+
+}
+
+func TestCortexActivate(t *testing.T) {
+	xnorCortex := XnorCortex()
+	outputs, err := xnorCortex.Activate([][]float64{[]float64{1, 1}})
+	assert.True(t, err == nil)
+	assert.Equals(t, len(outputs), 1)
+	assert.True(t, vectorEqualsWithMaxDelta(outputs[0], []float64{1}, 0.01))
+}
+
+func TestNeuronTimings(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+
+	// nothing is recorded unless profiling is switched on
+	xnorCortex.Fitness(XnorTrainingSamples())
+	timings := xnorCortex.NeuronTimings()
+	assert.Equals(t, len(timings), len(xnorCortex.Neurons))
+	for _, timing := range timings {
+		assert.Equals(t, timing, time.Duration(0))
+	}
+
+	// every neuron fires, so every neuron has spent some time computing
+	xnorCortex.Profiling = true
+	xnorCortex.Fitness(XnorTrainingSamples())
+	firingStatus := xnorCortex.FiringStatus()
+	timings = xnorCortex.NeuronTimings()
+	assert.Equals(t, len(timings), len(xnorCortex.Neurons))
+	for _, neuron := range xnorCortex.Neurons {
+		assert.True(t, firingStatus[neuron.NodeId.UUID])
+		timing, ok := timings[neuron.NodeId.UUID]
+		assert.True(t, ok)
+		assert.True(t, timing > 0)
+	}
+
+}
+
+func TestInferenceMode(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[2].Bias = -18
+	_, expected := xnorCortex.EvaluateWithPredictions(examples)
+
+	xnorCortex.InferenceMode = true
+	_, predictions := xnorCortex.EvaluateWithPredictions(examples)
+	assert.Equals(t, predictions, expected)
+
+	// analysis which needs to observe the run still works
+	assert.Equals(t, len(xnorCortex.Trace(examples[0].SampleInputs)), 8)
+	assert.True(t, xnorCortex.InferenceMode)
+
+	// observing doesn't touch InferenceMode, only the nodes launched while
+	// the observer is installed leave it off
+	xnorCortex.observe(&runObserver{}, func() {
+		assert.True(t, xnorCortex.InferenceMode)
+		assert.False(t, xnorCortex.runInInferenceMode())
+	})
+	assert.True(t, xnorCortex.runInInferenceMode())
+
+}
+
+func benchmarkActivate(b *testing.B, inferenceMode bool) {
+	cortex := NewFeedForwardCortex([]int{10, 20, 20, 1}, EncodableSigmoid())
+	cortex.InferenceMode = inferenceMode
+	samples := make([]*TrainingSample, 100)
+	for i, _ := range samples {
+		samples[i] = &TrainingSample{
+			SampleInputs:    [][]float64{RandomWeights(10)},
+			ExpectedOutputs: [][]float64{[]float64{0}},
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cortex.Fitness(samples)
+	}
+}
+
+func BenchmarkActivate(b *testing.B) {
+	benchmarkActivate(b, false)
+}
+
+func BenchmarkActivateInferenceMode(b *testing.B) {
+	benchmarkActivate(b, true)
+}
@@ -0,0 +1,106 @@
+package neurgo
+
+import (
+	"fmt"
+	"log"
+)
+
+// Build a cortex which runs the given cortexes side by side on the same
+// inputs and combines their outputs.  Its combine function isn't serialized.
+func EnsembleCortex(cortexes []*Cortex, combine CombineFunction) *Cortex {
+
+	if len(cortexes) == 0 {
+		log.Panicf("Cannot make an ensemble of no cortexes")
+	}
+
+	first := cortexes[0]
+	sensors := make([]*Sensor, len(first.Sensors))
+	for i, sensor := range first.Sensors {
+		sensors[i] = &Sensor{
+			NodeId:       NewSensorId(sensor.NodeId.UUID, sensor.NodeId.LayerIndex),
+			VectorLength: sensor.VectorLength,
+		}
+	}
+
+	if len(first.Actuators) != 1 {
+		log.Panicf("Ensemble members must have exactly one actuator")
+	}
+	actuator := &Actuator{
+		NodeId:       NewActuatorId(NewUuid(), first.Actuators[0].NodeId.LayerIndex),
+		VectorLength: first.Actuators[0].VectorLength,
+		combine:      combine,
+	}
+
+	neurons := make([]*Neuron, 0)
+
+	for i, cortex := range cortexes {
+
+		if len(cortex.Sensors) != len(sensors) {
+			log.Panicf("Ensemble member %v has %d sensors, expected %d",
+				cortex.NodeId.UUID, len(cortex.Sensors), len(sensors))
+		}
+		for j, sensor := range cortex.Sensors {
+			if sensor.VectorLength != sensors[j].VectorLength {
+				log.Panicf("Ensemble member %v sensor %v has vector length %d, expected %d",
+					cortex.NodeId.UUID, sensor.NodeId.UUID, sensor.VectorLength, sensors[j].VectorLength)
+			}
+		}
+		if len(cortex.Actuators) != 1 || cortex.Actuators[0].VectorLength != actuator.VectorLength {
+			log.Panicf("Ensemble member %v must have one actuator with vector length %d",
+				cortex.NodeId.UUID, actuator.VectorLength)
+		}
+
+		member := cortex.Copy()
+		memberActuator := member.Actuators[0]
+		if memberActuator.NodeId.LayerIndex > actuator.NodeId.LayerIndex {
+			actuator.NodeId.LayerIndex = memberActuator.NodeId.LayerIndex
+		}
+
+		// work out what each of the member's nodes is called in the ensemble
+		renamed := make(map[string]*NodeId)
+		for j, sensor := range member.Sensors {
+			renamed[sensor.NodeId.UUID] = sensors[j].NodeId
+		}
+		for _, neuron := range member.Neurons {
+			uuid := fmt.Sprintf("member%d-%v", i, neuron.NodeId.UUID)
+			renamed[neuron.NodeId.UUID] = NewNeuronId(uuid, neuron.NodeId.LayerIndex)
+		}
+		renamed[memberActuator.NodeId.UUID] = actuator.NodeId
+
+		for _, neuron := range member.Neurons {
+			neuron.NodeId = renamed[neuron.NodeId.UUID]
+			for _, inbound := range neuron.Inbound {
+				inbound.NodeId = renamed[inbound.NodeId.UUID]
+			}
+			for _, outbound := range neuron.Outbound {
+				// wired up to the ensemble's nodes when it's initialized
+				outbound.NodeId = renamed[outbound.NodeId.UUID]
+				outbound.DataChan = nil
+			}
+			neuron.Cortex = nil
+			neurons = append(neurons, neuron)
+		}
+		for j, sensor := range member.Sensors {
+			for _, outbound := range sensor.Outbound {
+				sensors[j].Outbound = append(sensors[j].Outbound, &OutboundConnection{
+					NodeId: renamed[outbound.NodeId.UUID],
+				})
+			}
+		}
+		for _, inbound := range memberActuator.Inbound {
+			actuator.Inbound = append(actuator.Inbound, &InboundConnection{
+				NodeId: renamed[inbound.NodeId.UUID],
+			})
+		}
+
+	}
+
+	ensemble := &Cortex{
+		NodeId: NewCortexId(NewUuid()),
+	}
+	ensemble.SetSensors(sensors)
+	ensemble.SetNeurons(neurons)
+	ensemble.SetActuators([]*Actuator{actuator})
+	return ensemble
+
+}
@@ -0,0 +1,45 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"testing"
+)
+
+func TestEnsembleCortex(t *testing.T) {
+
+	average := func(outputs [][]float64) []float64 {
+		result := make([]float64, len(outputs[0]))
+		for _, output := range outputs {
+			for i, value := range output {
+				result[i] += value / float64(len(outputs))
+			}
+		}
+		return result
+	}
+
+	// two xnor networks which give slightly different outputs
+	xnorCortex1 := XnorCortex()
+	xnorCortex2 := XnorCortex()
+	xnorCortex2.Neurons[2].Bias = -12
+
+	ensemble := EnsembleCortex([]*Cortex{xnorCortex1, xnorCortex2}, average)
+	assert.Equals(t, len(ensemble.Sensors), 1)
+	assert.Equals(t, len(ensemble.Neurons), 6)
+	assert.Equals(t, len(ensemble.Actuators), 1)
+
+	for _, example := range XnorTrainingSamples() {
+		outputs1, _ := xnorCortex1.Activate(example.SampleInputs)
+		outputs2, _ := xnorCortex2.Activate(example.SampleInputs)
+		expected := (outputs1[0][0] + outputs2[0][0]) / 2
+
+		outputs, err := ensemble.Activate(example.SampleInputs)
+		assert.True(t, err == nil)
+		assert.Equals(t, len(outputs[0]), 1)
+		assert.True(t, EqualsWithMaxDelta(outputs[0][0], expected, 1e-12))
+	}
+
+	// the members are left alone
+	assert.Equals(t, xnorCortex1.Neurons[0].NodeId.UUID, "hidden-neuron1")
+	assert.Equals(t, len(xnorCortex1.Sensors[0].Outbound), 2)
+
+}
@@ -0,0 +1,79 @@
+package neurgo
+
+import (
+	"fmt"
+	"log"
+)
+
+// Build a fully connected feed forward network with random weights, eg
+// layer sizes [2, 3, 1] give two inputs, three hidden neurons and one output.
+func NewFeedForwardCortex(layerSizes []int, activation *EncodableActivation) *Cortex {
+
+	if len(layerSizes) < 2 {
+		log.Panicf("Need at least an input and an output layer, got %v", layerSizes)
+	}
+	for _, layerSize := range layerSizes {
+		if layerSize < 1 {
+			log.Panicf("Layer sizes must be positive, got %v", layerSizes)
+		}
+	}
+
+	sensor := &Sensor{
+		NodeId:       NewSensorId("sensor", 0.0),
+		VectorLength: layerSizes[0],
+	}
+	sensor.Init()
+
+	actuator := &Actuator{
+		NodeId:       NewActuatorId("actuator", 1.0),
+		VectorLength: layerSizes[len(layerSizes)-1],
+	}
+	actuator.Init()
+
+	// the neuron layers are spread evenly between the sensor and actuator
+	numNeuronLayers := len(layerSizes) - 1
+	neurons := make([]*Neuron, 0)
+	previousLayer := make([]*Neuron, 0)
+	for layer := 1; layer <= numNeuronLayers; layer++ {
+		layerIndex := float64(layer) / float64(numNeuronLayers+1)
+		currentLayer := make([]*Neuron, 0)
+		for i := 0; i < layerSizes[layer]; i++ {
+			neuron := &Neuron{
+				ActivationFunction: activation,
+				NodeId:             NewNeuronId(fmt.Sprintf("neuron-%d-%d", layer, i), layerIndex),
+				Bias:               RandomBias(),
+			}
+			neuron.Init()
+
+			if layer == 1 {
+				sensor.ConnectOutbound(neuron)
+				neuron.ConnectInboundWeighted(sensor, RandomWeights(sensor.VectorLength))
+			}
+			for _, previous := range previousLayer {
+				previous.ConnectOutbound(neuron)
+				neuron.ConnectInboundWeighted(previous, RandomWeights(1))
+			}
+
+			currentLayer = append(currentLayer, neuron)
+		}
+		neurons = append(neurons, currentLayer...)
+		previousLayer = currentLayer
+	}
+
+	for _, neuron := range previousLayer {
+		neuron.ConnectOutbound(actuator)
+		actuator.ConnectInbound(neuron)
+	}
+
+	uuid := NewUuid()
+	cortexUuid := fmt.Sprintf("cortex-%s", uuid)
+	cortex := &Cortex{
+		NodeId: NewCortexId(cortexUuid),
+	}
+	cortex.SetSensors([]*Sensor{sensor})
+	cortex.SetNeurons(neurons)
+	cortex.SetActuators([]*Actuator{actuator})
+
+	return cortex
+
+}
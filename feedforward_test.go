@@ -0,0 +1,41 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"testing"
+)
+
+func TestNewFeedForwardCortex(t *testing.T) {
+
+	cortex := NewFeedForwardCortex([]int{2, 3, 1}, EncodableSigmoid())
+	assert.True(t, cortex.Validate())
+
+	assert.Equals(t, cortex.Sensors[0].VectorLength, 2)
+	assert.Equals(t, len(cortex.Neurons), 4)
+	assert.Equals(t, cortex.Actuators[0].VectorLength, 1)
+	widths := cortex.LayerWidths()
+	assert.Equals(t, len(widths), 2)
+	assert.Equals(t, widths[cortex.Neurons[0].NodeId.LayerIndex], 3)
+	assert.Equals(t, widths[cortex.Neurons[3].NodeId.LayerIndex], 1)
+
+	// every neuron is connected to the whole of the layer before it, and
+	// nothing is recurrent
+	for _, neuron := range cortex.Neurons {
+		assert.Equals(t, len(neuron.RecurrentInboundConnections()), 0)
+		assert.Equals(t, len(neuron.RecurrentOutboundConnections()), 0)
+	}
+	outputNeuron := cortex.Neurons[3]
+	assert.Equals(t, len(outputNeuron.Inbound), 3)
+	assert.Equals(t, len(cortex.Neurons[0].Inbound[0].Weights), 2)
+
+	outputs, err := cortex.Activate([][]float64{[]float64{1, 0}})
+	assert.True(t, err == nil)
+	assert.Equals(t, len(outputs[0]), 1)
+
+	// no hidden layers
+	cortex = NewFeedForwardCortex([]int{4, 2}, EncodableTanh())
+	assert.True(t, cortex.Validate())
+	assert.Equals(t, len(cortex.Neurons), 2)
+	assert.Equals(t, len(cortex.Actuators[0].Inbound), 2)
+
+}
@@ -0,0 +1,298 @@
+package neurgo
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// The gradient of the total sum of squares error over the samples with
+// respect to every weight and bias, by backpropagation, keyed by neuron UUID.
+func (cortex *Cortex) ComputeGradients(samples []*TrainingSample) (map[string][]float64, map[string]float64, error) {
+
+	if err := cortex.checkStateless(); err != nil {
+		return nil, nil, err
+	}
+
+	neurons := cortex.SortedNeurons()
+
+	grads := make(map[string][]float64)
+	biasGrads := make(map[string]float64)
+	for _, neuron := range neurons {
+		grads[neuron.NodeId.UUID] = make([]float64, 0)
+		for _, inbound := range neuron.Inbound {
+			grads[neuron.NodeId.UUID] = append(grads[neuron.NodeId.UUID], make([]float64, len(inbound.Weights))...)
+		}
+		biasGrads[neuron.NodeId.UUID] = 0
+	}
+
+	for _, sample := range samples {
+		if _, err := cortex.backpropagate(neurons, sample, grads, biasGrads); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	magnitudes := make(map[string]float64)
+	for uuid, neuronGrads := range grads {
+		sumSquares := 0.0
+		for _, grad := range neuronGrads {
+			sumSquares += grad * grad
+		}
+		magnitudes[uuid] = math.Sqrt(sumSquares)
+	}
+	cortex.gradientNorms = magnitudes
+
+	return grads, biasGrads, nil
+
+}
+
+// The L2 norm of each neuron's weight gradient from the last ComputeGradients.
+func (cortex *Cortex) LastGradientMagnitudes() map[string]float64 {
+	return cortex.gradientNorms
+}
+
+// How much each weight matters to the error on the samples, estimated as the
+// size of the weight times its gradient.
+func (cortex *Cortex) WeightSaliency(samples []*TrainingSample) map[string][]float64 {
+
+	grads, _, err := cortex.ComputeGradients(samples)
+	if err != nil {
+		log.Panicf("Cannot compute weight saliency: %v", err)
+	}
+
+	saliency := make(map[string][]float64)
+	for _, neuron := range cortex.Neurons {
+		uuid := neuron.NodeId.UUID
+		saliency[uuid] = make([]float64, 0)
+		k := 0
+		for _, inbound := range neuron.Inbound {
+			for _, weight := range inbound.Weights {
+				saliency[uuid] = append(saliency[uuid], math.Abs(weight*grads[uuid][k]))
+				k += 1
+			}
+		}
+	}
+	return saliency
+
+}
+
+// The sample's inputs moved by epsilon in the direction that increases its
+// error the most (the fast gradient sign method).
+func (cortex *Cortex) AdversarialInput(sample *TrainingSample, epsilon float64) [][]float64 {
+
+	if err := cortex.checkStateless(); err != nil {
+		log.Panicf("Cannot compute adversarial input: %v", err)
+	}
+	outputGrads, err := cortex.backpropagate(cortex.SortedNeurons(), sample, nil, nil)
+	if err != nil {
+		log.Panicf("Cannot compute adversarial input: %v", err)
+	}
+
+	perturbed := make([][]float64, len(cortex.Sensors))
+	for i, sensor := range cortex.Sensors {
+		inputGrads := outputGrads[sensor.NodeId.UUID]
+		perturbed[i] = make([]float64, len(sample.SampleInputs[i]))
+		for j, input := range sample.SampleInputs[i] {
+			switch grad := cortex.rawInputGradient(i, j, inputGrads[j]); {
+			case grad > 0:
+				perturbed[i][j] = input + epsilon
+			case grad < 0:
+				perturbed[i][j] = input - epsilon
+			default:
+				perturbed[i][j] = input
+			}
+		}
+	}
+	return perturbed
+
+}
+
+// Gradient ascent from zero inputs towards inputs which maximize the node's
+// output, with every input held within [lower, upper].
+func (cortex *Cortex) MaximizeActivation(nodeId *NodeId, iterations int, stepSize, lower, upper float64) ([][]float64, error) {
+
+	if err := cortex.checkStateless(); err != nil {
+		return nil, err
+	}
+	if lower > upper {
+		return nil, fmt.Errorf("lower bound %v is above upper bound %v", lower, upper)
+	}
+	if cortex.FindNeuron(nodeId) == nil {
+		return nil, fmt.Errorf("%v is not a neuron in this cortex", nodeId.UUID)
+	}
+
+	inputs := make([][]float64, len(cortex.Sensors))
+	for i, sensor := range cortex.Sensors {
+		inputs[i] = make([]float64, sensor.VectorLength)
+		for j, _ := range inputs[i] {
+			inputs[i][j] = Saturate(0, lower, upper)
+		}
+	}
+
+	neurons := cortex.SortedNeurons()
+	for iteration := 0; iteration < iterations; iteration++ {
+		outputs, weightedSums := cortex.forwardPass(neurons, inputs)
+		outputGrads := make(map[string][]float64)
+		for uuid, output := range outputs {
+			outputGrads[uuid] = make([]float64, len(output))
+		}
+		outputGrads[nodeId.UUID][0] = 1
+		cortex.backwardPass(neurons, outputs, weightedSums, outputGrads, nil, nil)
+		for i, sensor := range cortex.Sensors {
+			for j, grad := range outputGrads[sensor.NodeId.UUID] {
+				input := inputs[i][j] + stepSize*cortex.rawInputGradient(i, j, grad)
+				inputs[i][j] = Saturate(input, lower, upper)
+			}
+		}
+	}
+	return inputs, nil
+
+}
+
+// Add the gradients of a single sample onto grads and biasGrads (unless nil),
+// returning the gradient with respect to every sensor and neuron output.
+func (cortex *Cortex) backpropagate(neurons []*Neuron, sample *TrainingSample, grads map[string][]float64, biasGrads map[string]float64) (map[string][]float64, error) {
+
+	if len(sample.SampleInputs) != len(cortex.Sensors) {
+		return nil, fmt.Errorf("sample has %d input vectors for %d sensors", len(sample.SampleInputs), len(cortex.Sensors))
+	}
+	if len(sample.ExpectedOutputs) != len(cortex.Actuators) {
+		return nil, fmt.Errorf("sample has %d expected output vectors for %d actuators", len(sample.ExpectedOutputs), len(cortex.Actuators))
+	}
+
+	for i, sensor := range cortex.Sensors {
+		if len(sample.SampleInputs[i]) != sensor.VectorLength {
+			return nil, fmt.Errorf("input vector %v has length %d, expected %d", sample.SampleInputs[i], len(sample.SampleInputs[i]), sensor.VectorLength)
+		}
+	}
+	outputs, weightedSums := cortex.forwardPass(neurons, sample.SampleInputs)
+
+	// the error flows back into the nodes feeding each actuator
+	outputGrads := make(map[string][]float64)
+	for uuid, output := range outputs {
+		outputGrads[uuid] = make([]float64, len(output))
+	}
+	for i, actuator := range cortex.Actuators {
+		expected := sample.ExpectedOutputs[i]
+		if len(expected) != len(actuator.Inbound) {
+			return nil, fmt.Errorf("expected output vector %v has length %d, expected %d", expected, len(expected), len(actuator.Inbound))
+		}
+		for j, inbound := range actuator.Inbound {
+			actual := outputs[inbound.NodeId.UUID][0]
+			outputGrads[inbound.NodeId.UUID][0] += 2 * (actual - expected[j])
+		}
+	}
+
+	cortex.backwardPass(neurons, outputs, weightedSums, outputGrads, grads, biasGrads)
+	return outputGrads, nil
+
+}
+
+// Propagate the output gradients back through the network, from the last
+// layer to the first, given the results of forwardPass.
+func (cortex *Cortex) backwardPass(neurons []*Neuron, outputs map[string][]float64, weightedSums map[string]float64, outputGrads map[string][]float64, grads map[string][]float64, biasGrads map[string]float64) {
+
+	for i := len(neurons) - 1; i >= 0; i-- {
+		neuron := neurons[i]
+		uuid := neuron.NodeId.UUID
+		delta := outputGrads[uuid][0] * activationDerivative(neuron.ActivationFunction, weightedSums[uuid])
+		if neuron.BatchNorm != nil {
+			// the statistics are fixed, so normalizing just scales
+			delta *= neuron.BatchNorm.scale()
+		}
+		if biasGrads != nil {
+			biasGrads[uuid] += delta
+		}
+		k := 0
+		for _, inbound := range neuron.Inbound {
+			if inbound.Disabled {
+				k += len(inbound.Weights)
+				continue
+			}
+			inputs := outputs[inbound.NodeId.UUID]
+			for j, weight := range inbound.Weights {
+				if grads != nil {
+					grads[uuid][k] += delta * inputs[j]
+				}
+				outputGrads[inbound.NodeId.UUID][j] += delta * weight
+				k += 1
+			}
+		}
+	}
+
+}
+
+// Undo the Scaler's effect on the gradient with respect to sensor i's input.
+func (cortex *Cortex) rawInputGradient(i, j int, grad float64) float64 {
+	if cortex.Scaler == nil {
+		return grad
+	}
+	return grad * cortex.Scaler.slope(i, j)
+}
+
+// Evaluate the network directly, returning the output of every sensor and
+// neuron and the weighted sum of every neuron, keyed by UUID.
+func (cortex *Cortex) forwardPass(neurons []*Neuron, inputs [][]float64) (map[string][]float64, map[string]float64) {
+	inputs = cortex.scaleInputs(inputs)
+	outputs := make(map[string][]float64)
+	for i, sensor := range cortex.Sensors {
+		outputs[sensor.NodeId.UUID] = inputs[i]
+	}
+	weightedSums := make(map[string]float64)
+	for _, neuron := range neurons {
+		weightedSums[neuron.NodeId.UUID] = neuron.forwardPass(outputs)
+	}
+	return outputs, weightedSums
+}
+
+// Evaluate a single neuron, storing its output and returning its weighted sum.
+func (neuron *Neuron) forwardPass(outputs map[string][]float64) float64 {
+	weightedSum := neuron.batchNormalize(neuron.rawWeightedSum(outputs))
+	outputs[neuron.NodeId.UUID] = []float64{neuron.ActivationFunction.ActivationFunction(weightedSum)}
+	return weightedSum
+}
+
+// The neuron's weighted sum plus bias, on the outputs of the nodes feeding
+// it, before any batch normalization.
+func (neuron *Neuron) rawWeightedSum(outputs map[string][]float64) float64 {
+	weightedSum := neuron.Bias
+	for _, inbound := range neuron.Inbound {
+		if inbound.Disabled {
+			continue
+		}
+		for j, weight := range inbound.Weights {
+			weightedSum += weight * outputs[inbound.NodeId.UUID][j]
+		}
+	}
+	return weightedSum
+}
+
+// Make sure the network's output is a function of its parameters and
+// current inputs alone, with no state carried between passes.  Anything that
+// evaluates the network directly rather than through its nodes (gradients,
+// batch norm, code generation, relevance, FitnessDelta, ActivateInto) relies
+// on this, so recurrent connections, time constants and ensembles are out.
+func (cortex *Cortex) checkStateless() error {
+	for _, neuron := range cortex.Neurons {
+		if len(neuron.RecurrentInboundConnections()) > 0 {
+			return fmt.Errorf("%v has recurrent inbound connections", neuron.NodeId.UUID)
+		}
+		if neuron.TimeConstant != 0 {
+			return fmt.Errorf("%v has a time constant", neuron.NodeId.UUID)
+		}
+	}
+	for _, actuator := range cortex.Actuators {
+		if actuator.combine != nil {
+			return fmt.Errorf("%v combines the outputs of several networks", actuator.NodeId.UUID)
+		}
+	}
+	return nil
+}
+
+// Estimate the slope of the activation function at x with a central
+// difference.
+func activationDerivative(activation *EncodableActivation, x float64) float64 {
+	h := 1e-6
+	f := activation.ActivationFunction
+	return (f(x+h) - f(x-h)) / (2 * h)
+}
@@ -0,0 +1,204 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestComputeGradients(t *testing.T) {
+
+	// small weights, so that none of the sigmoids are saturated
+	xnorCortex := XnorCortex()
+	parameters := []float64{
+		0.5, -0.3, 0.1,
+		-0.7, 0.2, -0.4,
+		0.8, 0.6, 0.3,
+	}
+	xnorCortex.SetParameters(parameters)
+	examples := XnorTrainingSamples()
+
+	grads, biasGrads, err := xnorCortex.ComputeGradients(examples)
+	assert.True(t, err == nil)
+
+	// lay the gradients out like GetParameters
+	analytic := make([]float64, 0)
+	for _, neuron := range xnorCortex.SortedNeurons() {
+		analytic = append(analytic, grads[neuron.NodeId.UUID]...)
+		analytic = append(analytic, biasGrads[neuron.NodeId.UUID])
+	}
+	assert.Equals(t, len(analytic), len(parameters))
+
+	// compare against central differences of the error from actually
+	// running the network
+	h := 1e-5
+	for i, _ := range parameters {
+		perturbed := make([]float64, len(parameters))
+		copy(perturbed, parameters)
+		perturbed[i] = parameters[i] + h
+		xnorCortex.SetParameters(perturbed)
+		errorPlus := 1 / xnorCortex.Fitness(examples)
+		perturbed[i] = parameters[i] - h
+		xnorCortex.SetParameters(perturbed)
+		errorMinus := 1 / xnorCortex.Fitness(examples)
+		numerical := (errorPlus - errorMinus) / (2 * h)
+		assert.True(t, EqualsWithMaxDelta(analytic[i], numerical, 1e-4))
+	}
+
+	// nothing was changed
+	xnorCortex.SetParameters(parameters)
+	grads2, _, _ := xnorCortex.ComputeGradients(examples)
+	assert.Equals(t, xnorCortex.GetParameters(), parameters)
+	assert.Equals(t, grads2, grads)
+
+}
+
+func TestComputeGradientsRecurrent(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	outputNeuron := xnorCortex.Neurons[2]
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	outputNeuron.ConnectOutbound(hiddenNeuron1)
+	hiddenNeuron1.ConnectInboundWeighted(outputNeuron, []float64{1})
+
+	_, _, err := xnorCortex.ComputeGradients(XnorTrainingSamples())
+	assert.True(t, err != nil)
+
+}
+
+func TestAdversarialInput(t *testing.T) {
+
+	// small weights, so that none of the sigmoids are saturated
+	xnorCortex := XnorCortex()
+	parameters := []float64{
+		0.5, -0.3, 0.1,
+		-0.7, 0.2, -0.4,
+		0.8, 0.6, 0.3,
+	}
+	xnorCortex.SetParameters(parameters)
+
+	epsilon := 0.2
+	for _, example := range XnorTrainingSamples() {
+
+		perturbed := xnorCortex.AdversarialInput(example, epsilon)
+		for j, input := range example.SampleInputs[0] {
+			assert.True(t, math.Abs(perturbed[0][j]-input) <= epsilon+1e-12)
+		}
+
+		clean, _ := xnorCortex.Activate(example.SampleInputs)
+		adversarial, _ := xnorCortex.Activate(perturbed)
+		cleanError := SumOfSquaresError(example.ExpectedOutputs[0], clean[0])
+		adversarialError := SumOfSquaresError(example.ExpectedOutputs[0], adversarial[0])
+		assert.True(t, adversarialError > cleanError)
+
+	}
+
+}
+
+func TestWeightSaliency(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	parameters := []float64{
+		0.5, -0.3, 0.1,
+		-0.7, 0.2, -0.4,
+		0.8, 0.6, 0.3,
+	}
+	xnorCortex.SetParameters(parameters)
+
+	// a neuron whose output goes nowhere, so its weight can't matter
+	sensor := xnorCortex.Sensors[0]
+	unused := xnorCortex.CreateNeuronInLayer(0.25)
+	unused.ActivationFunction = EncodableSigmoid()
+	sensor.ConnectOutbound(unused)
+	unused.ConnectInboundWeighted(sensor, []float64{1.5, -1.5})
+
+	saliency := xnorCortex.WeightSaliency(XnorTrainingSamples())
+	for _, neuron := range xnorCortex.Neurons {
+		assert.Equals(t, len(saliency[neuron.NodeId.UUID]), 2)
+	}
+	for _, value := range saliency[unused.NodeId.UUID] {
+		assert.True(t, value < 1e-12)
+	}
+	outputNeuron := xnorCortex.Neurons[2]
+	for _, value := range saliency[outputNeuron.NodeId.UUID] {
+		assert.True(t, value > 1e-3)
+	}
+
+}
+
+func TestMaximizeActivation(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	parameters := []float64{
+		0.5, -0.3, 0.1,
+		-0.7, 0.2, -0.4,
+		0.8, 0.6, 0.3,
+	}
+	xnorCortex.SetParameters(parameters)
+
+	neurons := xnorCortex.SortedNeurons()
+	target := neurons[len(neurons)-1]
+	activation := func(inputs [][]float64) float64 {
+		outputs, _ := xnorCortex.forwardPass(neurons, inputs)
+		return outputs[target.NodeId.UUID][0]
+	}
+
+	optimized, err := xnorCortex.MaximizeActivation(target.NodeId, 100, 0.5, -1, 1)
+	assert.True(t, err == nil)
+	assert.Equals(t, len(optimized), 1)
+	assert.Equals(t, len(optimized[0]), 2)
+
+	rng := rand.New(rand.NewSource(1))
+	random := [][]float64{[]float64{rng.Float64()*2 - 1, rng.Float64()*2 - 1}}
+	assert.True(t, activation(optimized) > activation(random))
+
+	_, err = xnorCortex.MaximizeActivation(xnorCortex.Sensors[0].NodeId, 100, 0.5, -1, 1)
+	assert.True(t, err != nil)
+	_, err = xnorCortex.MaximizeActivation(target.NodeId, 100, 0.5, 1, -1)
+	assert.True(t, err != nil)
+
+	// an identity neuron never saturates, so its input would grow without
+	// bound, but it's held within the bounds
+	target.ActivationFunction = EncodableIdentity()
+	optimized, err = xnorCortex.MaximizeActivation(target.NodeId, 1000, 0.5, -1, 1)
+	assert.True(t, err == nil)
+	for _, input := range optimized[0] {
+		assert.True(t, input >= -1 && input <= 1)
+	}
+
+}
+
+func TestLastGradientMagnitudes(t *testing.T) {
+
+	cortex := NewFeedForwardCortex([]int{2, 2, 2, 2, 2, 1}, EncodableSigmoid())
+	assert.True(t, cortex.LastGradientMagnitudes() == nil)
+
+	cortex.InitWeights(func(fromId, toId *NodeId, index int) float64 {
+		return 1.0
+	})
+	for _, neuron := range cortex.Neurons {
+		neuron.Bias = 0
+	}
+	_, _, err := cortex.ComputeGradients(XnorTrainingSamples())
+	assert.True(t, err == nil)
+
+	magnitudes := cortex.LastGradientMagnitudes()
+	assert.Equals(t, len(magnitudes), len(cortex.Neurons))
+
+	// the gradient shrinks with every sigmoid it passes back through
+	layerIndexes := cortex.NeuronLayerMap().Keys()
+	sort.Float64s(layerIndexes)
+	layerMagnitudes := make([]float64, len(layerIndexes))
+	for i, layerIndex := range layerIndexes {
+		for _, neuron := range cortex.NeuronLayerMap()[layerIndex] {
+			layerMagnitudes[i] += magnitudes[neuron.NodeId.UUID]
+		}
+		layerMagnitudes[i] /= float64(len(cortex.NeuronLayerMap()[layerIndex]))
+	}
+	for i := 1; i < len(layerMagnitudes); i++ {
+		assert.True(t, layerMagnitudes[i-1] < layerMagnitudes[i])
+	}
+
+}
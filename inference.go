@@ -0,0 +1,218 @@
+package neurgo
+
+// A stateless network flattened out so that it can be evaluated without
+// goroutines or allocations.  Only topology changes make it stale.
+type inferencePlan struct {
+	sensors      []*Sensor
+	sensorInputs []plannedInput // the slot and width of each sensor's input
+	neurons      []*Neuron      // cortex.Neurons when the plan was made
+	steps        []inferenceStep
+	actuators    []plannedActuator
+	values       []float64
+}
+
+type inferenceStep struct {
+	neuron     *Neuron
+	layerIndex float64
+	inbound    []plannedInput
+	slot       int
+}
+
+type plannedActuator struct {
+	actuator *Actuator
+	inbound  []plannedInput
+}
+
+// Where a connection's inputs are found in the values
+type plannedInput struct {
+	connection *InboundConnection
+	senderUUID string
+	slot       int
+	width      int
+}
+
+// Plan the evaluation of the cortex, or return nil if it can only be run
+// by its nodes.
+func newInferencePlan(cortex *Cortex) *inferencePlan {
+
+	if err := cortex.checkStateless(); err != nil {
+		return nil
+	}
+
+	plan := &inferencePlan{
+		sensors:      make([]*Sensor, len(cortex.Sensors)),
+		sensorInputs: make([]plannedInput, len(cortex.Sensors)),
+		neurons:      make([]*Neuron, len(cortex.Neurons)),
+		steps:        make([]inferenceStep, 0),
+		actuators:    make([]plannedActuator, 0),
+	}
+	copy(plan.sensors, cortex.Sensors)
+	copy(plan.neurons, cortex.Neurons)
+
+	// the slot and width of the output of every node evaluated so far
+	outputs := make(map[string]plannedInput)
+	numSlots := 0
+	for i, sensor := range cortex.Sensors {
+		plan.sensorInputs[i] = plannedInput{slot: numSlots, width: sensor.VectorLength}
+		outputs[sensor.NodeId.UUID] = plan.sensorInputs[i]
+		numSlots += sensor.VectorLength
+	}
+
+	planInputs := func(connections []*InboundConnection) []plannedInput {
+		inputs := make([]plannedInput, len(connections))
+		for i, connection := range connections {
+			output, ok := outputs[connection.NodeId.UUID]
+			if !ok || len(connection.Weights) > output.width {
+				return nil
+			}
+			inputs[i] = plannedInput{
+				connection: connection,
+				senderUUID: connection.NodeId.UUID,
+				slot:       output.slot,
+				width:      output.width,
+			}
+		}
+		return inputs
+	}
+
+	for _, neuron := range cortex.SortedNeurons() {
+		if neuron.ActivationFunction == nil {
+			return nil
+		}
+		inbound := planInputs(neuron.Inbound)
+		if inbound == nil {
+			return nil
+		}
+		plan.steps = append(plan.steps, inferenceStep{
+			neuron:     neuron,
+			layerIndex: neuron.NodeId.LayerIndex,
+			inbound:    inbound,
+			slot:       numSlots,
+		})
+		outputs[neuron.NodeId.UUID] = plannedInput{slot: numSlots, width: 1}
+		numSlots += 1
+	}
+
+	for _, actuator := range cortex.Actuators {
+		if actuator.OutputChan != nil {
+			return nil
+		}
+		inbound := planInputs(actuator.Inbound)
+		if inbound == nil {
+			return nil
+		}
+		plan.actuators = append(plan.actuators, plannedActuator{
+			actuator: actuator,
+			inbound:  inbound,
+		})
+	}
+
+	plan.values = make([]float64, numSlots)
+	return plan
+
+}
+
+// Is the plan still valid for the cortex?  This has to be checked before
+// every pass, so it mustn't allocate.
+func (plan *inferencePlan) current(cortex *Cortex) bool {
+
+	if len(cortex.Sensors) != len(plan.sensors) || len(cortex.Neurons) != len(plan.neurons) {
+		return false
+	}
+	if len(cortex.Actuators) != len(plan.actuators) {
+		return false
+	}
+	for i, sensor := range cortex.Sensors {
+		if sensor != plan.sensors[i] || sensor.VectorLength != plan.sensorInputs[i].width {
+			return false
+		}
+	}
+	for i, neuron := range cortex.Neurons {
+		if neuron != plan.neurons[i] {
+			return false
+		}
+	}
+	for _, step := range plan.steps {
+		neuron := step.neuron
+		if neuron.NodeId.LayerIndex != step.layerIndex || neuron.TimeConstant != 0 {
+			return false
+		}
+		if neuron.ActivationFunction == nil || !inputsCurrent(neuron.Inbound, step.inbound) {
+			return false
+		}
+		for _, inbound := range neuron.Inbound {
+			if neuron.IsInboundConnectionRecurrent(inbound) {
+				return false
+			}
+		}
+	}
+	for i, actuator := range cortex.Actuators {
+		planned := plan.actuators[i]
+		if actuator != planned.actuator || actuator.combine != nil || actuator.OutputChan != nil {
+			return false
+		}
+		if !inputsCurrent(actuator.Inbound, planned.inbound) {
+			return false
+		}
+	}
+	return true
+
+}
+
+func inputsCurrent(connections []*InboundConnection, inputs []plannedInput) bool {
+	if len(connections) != len(inputs) {
+		return false
+	}
+	for i, connection := range connections {
+		input := inputs[i]
+		if connection != input.connection || connection.NodeId.UUID != input.senderUUID {
+			return false
+		}
+		if len(connection.Weights) > input.width {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate the network, writing what each actuator would receive into outputs.
+func (plan *inferencePlan) run(scaler *FeatureScaler, inputs [][]float64, outputs [][]float64) {
+
+	values := plan.values
+	for i, sensorInput := range plan.sensorInputs {
+		for j, input := range inputs[i] {
+			if scaler != nil {
+				input = scaler.scale(i, j, input)
+			}
+			values[sensorInput.slot+j] = input
+		}
+	}
+
+	for _, step := range plan.steps {
+		// summed in the same order as the neuron's own weighted sum, so
+		// the results are exactly the same
+		neuron := step.neuron
+		weightedSum := 0.0
+		for _, input := range step.inbound {
+			if input.connection.Disabled {
+				continue
+			}
+			dotProduct := 0.0
+			for j, weight := range input.connection.Weights {
+				dotProduct += values[input.slot+j] * weight
+			}
+			weightedSum += dotProduct
+		}
+		weightedSum = neuron.batchNormalize(weightedSum + neuron.Bias)
+		values[step.slot] = neuron.ActivationFunction.ActivationFunction(weightedSum)
+	}
+
+	for i, planned := range plan.actuators {
+		for j, input := range planned.inbound {
+			if j < len(outputs[i]) {
+				outputs[i][j] = values[input.slot]
+			}
+		}
+	}
+
+}
@@ -4,8 +4,11 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 )
 
 func WriteStringToFile(value string, filepath string) error {
@@ -30,3 +33,56 @@ func JsonString(v interface{}) string {
 	}
 	return fmt.Sprintf("%s", json)
 }
+
+// Read whitespace separated inputs from r, one line per pass, and write the
+// outputs to w the same way until EOF.
+func (cortex *Cortex) ServeInteractive(r io.Reader, w io.Writer) error {
+
+	inputLength := 0
+	for _, sensor := range cortex.Sensors {
+		inputLength += sensor.VectorLength
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != inputLength {
+			return fmt.Errorf("got %d inputs, expected %d: %q", len(fields), inputLength, scanner.Text())
+		}
+
+		inputs := make([][]float64, len(cortex.Sensors))
+		k := 0
+		for i, sensor := range cortex.Sensors {
+			inputs[i] = make([]float64, sensor.VectorLength)
+			for j, _ := range inputs[i] {
+				value, err := strconv.ParseFloat(fields[k], 64)
+				if err != nil {
+					return err
+				}
+				inputs[i][j] = value
+				k += 1
+			}
+		}
+
+		outputs, err := cortex.Activate(inputs)
+		if err != nil {
+			return err
+		}
+		values := make([]string, 0)
+		for _, actuatorOutputs := range outputs {
+			for _, output := range actuatorOutputs {
+				values = append(values, strconv.FormatFloat(output, 'g', -1, 64))
+			}
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(values, " ")); err != nil {
+			return err
+		}
+
+	}
+	return scanner.Err()
+
+}
@@ -0,0 +1,30 @@
+package neurgo
+
+import (
+	"bytes"
+	"github.com/couchbaselabs/go.assert"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestServeInteractive(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	input := strings.NewReader("0 1\n1 1\n\n1 0\n0 0\n")
+	output := &bytes.Buffer{}
+	assert.True(t, xnorCortex.ServeInteractive(input, output) == nil)
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equals(t, len(lines), 4)
+	for i, sample := range XnorTrainingSamples() {
+		value, err := strconv.ParseFloat(lines[i], 64)
+		assert.True(t, err == nil)
+		assert.True(t, EqualsWithMaxDelta(value, sample.ExpectedOutputs[0][0], 0.01))
+	}
+
+	// the wrong number of inputs
+	err := xnorCortex.ServeInteractive(strings.NewReader("0 1 1\n"), output)
+	assert.True(t, err != nil)
+
+}
@@ -0,0 +1,141 @@
+package neurgo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long a node can be blocked sending a message before HealthCheck()
+// reports it as stuck
+const STUCK_SEND_TIMEOUT = 5 * time.Second
+
+// Tracks the node goroutines of a cortex launched via Start().
+type nodeLiveness struct {
+	mutex      sync.Mutex
+	starting   *sync.WaitGroup
+	started    map[string]bool
+	exited     map[string]bool
+	stopping   bool
+	failures   map[string]string
+	sends      map[string]pendingSend // keyed by sender UUID
+	stuckAfter time.Duration
+}
+
+// A message a node is blocked trying to send
+type pendingSend struct {
+	receiver string
+	since    time.Time
+}
+
+func newNodeLiveness(numNodes int) *nodeLiveness {
+	liveness := &nodeLiveness{
+		starting:   &sync.WaitGroup{},
+		started:    make(map[string]bool),
+		exited:     make(map[string]bool),
+		failures:   make(map[string]string),
+		sends:      make(map[string]pendingSend),
+		stuckAfter: STUCK_SEND_TIMEOUT,
+	}
+	liveness.starting.Add(numNodes)
+	return liveness
+}
+
+func (liveness *nodeLiveness) nodeRunning(nodeId *NodeId) {
+	liveness.mutex.Lock()
+	defer liveness.mutex.Unlock()
+	liveness.markStarted(nodeId)
+}
+
+// Called when a node goroutine returns, with the recovered panic value
+// (if any).  Exits are only considered failures before stop() is called.
+func (liveness *nodeLiveness) nodeExited(nodeId *NodeId, panicValue interface{}) {
+	liveness.mutex.Lock()
+	defer liveness.mutex.Unlock()
+
+	// a node which dies during startup must not hold up Start()
+	liveness.markStarted(nodeId)
+	liveness.exited[nodeId.UUID] = true
+	delete(liveness.sends, nodeId.UUID)
+
+	if liveness.stopping {
+		return
+	}
+	if panicValue != nil {
+		liveness.failures[nodeId.UUID] = fmt.Sprintf("%v panicked: %v", nodeId.UUID, panicValue)
+	} else {
+		liveness.failures[nodeId.UUID] = fmt.Sprintf("%v exited unexpectedly", nodeId.UUID)
+	}
+}
+
+// Has the node's goroutine returned?  If so, nothing is listening on its
+// channels any more.
+func (liveness *nodeLiveness) hasExited(nodeId *NodeId) bool {
+	liveness.mutex.Lock()
+	defer liveness.mutex.Unlock()
+	return liveness.exited[nodeId.UUID]
+}
+
+// Called by a node before it blocks sending a message, and again (with an
+// empty receiver) once the message has gone.
+func (liveness *nodeLiveness) sending(senderId *NodeId, receiver string) {
+	liveness.mutex.Lock()
+	defer liveness.mutex.Unlock()
+	if receiver == "" {
+		delete(liveness.sends, senderId.UUID)
+	} else {
+		liveness.sends[senderId.UUID] = pendingSend{receiver: receiver, since: time.Now()}
+	}
+}
+
+func (liveness *nodeLiveness) markStarted(nodeId *NodeId) {
+	if !liveness.started[nodeId.UUID] {
+		liveness.started[nodeId.UUID] = true
+		liveness.starting.Done()
+	}
+}
+
+func (liveness *nodeLiveness) waitUntilRunning() {
+	liveness.starting.Wait()
+}
+
+func (liveness *nodeLiveness) stop() {
+	liveness.mutex.Lock()
+	defer liveness.mutex.Unlock()
+	liveness.stopping = true
+}
+
+func (liveness *nodeLiveness) check() error {
+	liveness.mutex.Lock()
+	defer liveness.mutex.Unlock()
+
+	problems := make(map[string]string)
+	for uuid, failure := range liveness.failures {
+		problems[uuid] = failure
+	}
+	if !liveness.stopping {
+		for uuid, send := range liveness.sends {
+			if blocked := time.Since(send.since); blocked > liveness.stuckAfter {
+				problems[uuid] = fmt.Sprintf("%v has been blocked sending to %v for %v", uuid, send.receiver, blocked)
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	uuids := make([]string, 0)
+	for uuid, _ := range problems {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	messages := make([]string, 0)
+	for _, uuid := range uuids {
+		messages = append(messages, problems[uuid])
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
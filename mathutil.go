@@ -64,6 +64,45 @@ func SumOfSquaresError(expected []float64, actual []float64) float64 {
 	return result
 }
 
+// The average of the absolute differences between the vectors, or 0 if
+// they're empty
+func MeanAbsoluteError(expected []float64, actual []float64) float64 {
+
+	result := float64(0)
+	if len(expected) != len(actual) {
+		msg := fmt.Sprintf("vector lengths dont match (%d != %d)", len(expected), len(actual))
+		panic(msg)
+	}
+	if len(expected) == 0 {
+		return result
+	}
+
+	for i, expectedVal := range expected {
+		result += math.Abs(actual[i] - expectedVal)
+	}
+
+	return result / float64(len(expected))
+}
+
+// The binary cross entropy of the actual probabilities against the expected
+// ones, summed over the vector.  http://en.wikipedia.org/wiki/Cross_entropy
+func CrossEntropyError(expected []float64, actual []float64) float64 {
+
+	result := float64(0)
+	if len(expected) != len(actual) {
+		msg := fmt.Sprintf("vector lengths dont match (%d != %d)", len(expected), len(actual))
+		panic(msg)
+	}
+
+	epsilon := 0.000000001
+	for i, expectedVal := range expected {
+		actualVal := Saturate(actual[i], epsilon, 1-epsilon)
+		result -= expectedVal*math.Log(actualVal) + (1-expectedVal)*math.Log(1-actualVal)
+	}
+
+	return result
+}
+
 func EqualsWithMaxDelta(x, y, maxDelta float64) bool {
 	delta := math.Abs(x - y)
 	return delta <= maxDelta
@@ -158,3 +197,54 @@ func Average(xs []float64) float64 {
 	}
 	return total / float64(len(xs))
 }
+
+func Variance(xs []float64) float64 {
+	mean := Average(xs)
+	total := float64(0)
+	for _, x := range xs {
+		total += math.Pow(x-mean, 2)
+	}
+	return total / float64(len(xs))
+}
+
+// The Pearson correlation of two equal length series, or NaN if either is constant.
+func PearsonCorrelation(xs, ys []float64) float64 {
+	if len(xs) != len(ys) {
+		msg := fmt.Sprintf("vector lengths dont match (%d != %d)", len(xs), len(ys))
+		panic(msg)
+	}
+	xMean, yMean := Average(xs), Average(ys)
+	covariance, xSquares, ySquares := 0.0, 0.0, 0.0
+	for i, x := range xs {
+		covariance += (x - xMean) * (ys[i] - yMean)
+		xSquares += (x - xMean) * (x - xMean)
+		ySquares += (ys[i] - yMean) * (ys[i] - yMean)
+	}
+	if xSquares == 0 || ySquares == 0 {
+		return math.NaN()
+	}
+	return covariance / math.Sqrt(xSquares*ySquares)
+}
+
+// The Shannon entropy (in nats) of an output read as a probability distribution.
+func OutputEntropy(output []float64) float64 {
+	entropy := float64(0)
+	for _, probability := range output {
+		if probability > 0 {
+			entropy -= probability * math.Log(probability)
+		}
+	}
+	return entropy
+}
+
+// The index of the largest value, taking the lowest index if there's a
+// tie, or -1 if there are no values
+func ArgMax(values []float64) int {
+	maxIndex := -1
+	for i, value := range values {
+		if maxIndex == -1 || value > values[maxIndex] {
+			maxIndex = i
+		}
+	}
+	return maxIndex
+}
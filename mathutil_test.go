@@ -39,6 +39,26 @@ func TestSumOfSquaresError(t *testing.T) {
 	assert.True(t, nearlyEqualsPoint25)
 }
 
+func TestMeanAbsoluteError(t *testing.T) {
+	error := MeanAbsoluteError([]float64{0, 1, 1}, []float64{0.5, 1, -1})
+	assert.True(t, EqualsWithMaxDelta(error, 2.5/3, 1e-12))
+
+	// like the other error functions, empty vectors have no error
+	assert.Equals(t, MeanAbsoluteError([]float64{}, []float64{}), 0.0)
+	assert.Equals(t, SumOfSquaresError([]float64{}, []float64{}), 0.0)
+	assert.Equals(t, CrossEntropyError([]float64{}, []float64{}), 0.0)
+}
+
+func TestCrossEntropyError(t *testing.T) {
+	assert.True(t, EqualsWithMaxDelta(CrossEntropyError([]float64{1}, []float64{0.5}), math.Log(2), 1e-12))
+	assert.True(t, CrossEntropyError([]float64{1, 0}, []float64{0.9, 0.1}) < CrossEntropyError([]float64{1, 0}, []float64{0.6, 0.4}))
+
+	// confidently wrong is heavily penalized, but not infinitely
+	error := CrossEntropyError([]float64{1}, []float64{0})
+	assert.True(t, error > 20)
+	assert.False(t, math.IsInf(error, 0))
+}
+
 func TestSafeScalarInverse(t *testing.T) {
 	value := SafeScalarInverse(0)
 	assert.True(t, value > 1000000)
@@ -124,3 +144,35 @@ func TestNormalizeInRange(t *testing.T) {
 	}
 
 }
+
+func TestVariance(t *testing.T) {
+	assert.Equals(t, Variance([]float64{2, 2, 2}), 0.0)
+	assert.True(t, EqualsWithMaxDelta(Variance([]float64{0, 1, 0, 1}), 0.25, 1e-9))
+}
+
+func TestPearsonCorrelation(t *testing.T) {
+	xs := []float64{1, 2, 3, 4}
+	assert.True(t, EqualsWithMaxDelta(PearsonCorrelation(xs, []float64{3, 5, 7, 9}), 1.0, 1e-12))
+	assert.True(t, EqualsWithMaxDelta(PearsonCorrelation(xs, []float64{4, 3, 2, 1}), -1.0, 1e-12))
+	assert.True(t, math.IsNaN(PearsonCorrelation(xs, []float64{2, 2, 2, 2})))
+}
+
+func TestOutputEntropy(t *testing.T) {
+
+	uniform := OutputEntropy([]float64{0.25, 0.25, 0.25, 0.25})
+	assert.True(t, EqualsWithMaxDelta(uniform, math.Log(4), 1e-12))
+
+	skewed := OutputEntropy([]float64{0.7, 0.1, 0.1, 0.1})
+	assert.True(t, skewed < uniform)
+
+	assert.Equals(t, OutputEntropy([]float64{0, 1, 0, 0}), 0.0)
+	assert.True(t, OutputEntropy([]float64{1e-9, 1 - 2e-9, 1e-9}) < 1e-6)
+
+}
+
+func TestArgMax(t *testing.T) {
+	assert.Equals(t, ArgMax([]float64{0.1, 0.7, 0.2}), 1)
+	assert.Equals(t, ArgMax([]float64{-3, -1, -2}), 1)
+	assert.Equals(t, ArgMax([]float64{0.5, 0.2, 0.5}), 0)
+	assert.Equals(t, ArgMax([]float64{}), -1)
+}
@@ -0,0 +1,226 @@
+package neurgo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Fill in the fields (node ids, node types, activation functions, vector
+// lengths, layer indices) that a cortex saved by an older version may lack.
+func (cortex *Cortex) Migrate() error {
+
+	if cortex.NodeId == nil {
+		cortex.NodeId = NewCortexId(fmt.Sprintf("cortex-%s", NewUuid()))
+	}
+	cortex.NodeId.NodeType = CORTEX
+
+	nodeTypes := make(map[string]NodeType)
+	for _, sensor := range cortex.Sensors {
+		if sensor.NodeId == nil {
+			return errors.New("found a sensor with no NodeId")
+		}
+		sensor.NodeId.NodeType = SENSOR
+		nodeTypes[sensor.NodeId.UUID] = SENSOR
+	}
+	for _, neuron := range cortex.Neurons {
+		if neuron.NodeId == nil {
+			return errors.New("found a neuron with no NodeId")
+		}
+		neuron.NodeId.NodeType = NEURON
+		nodeTypes[neuron.NodeId.UUID] = NEURON
+	}
+	for _, actuator := range cortex.Actuators {
+		if actuator.NodeId == nil {
+			return errors.New("found an actuator with no NodeId")
+		}
+		actuator.NodeId.NodeType = ACTUATOR
+		nodeTypes[actuator.NodeId.UUID] = ACTUATOR
+	}
+	for _, nodeId := range cortex.allNodeIdReferences() {
+		nodeType, ok := nodeTypes[nodeId.UUID]
+		if !ok {
+			return fmt.Errorf("found a connection to unknown node %v", nodeId.UUID)
+		}
+		nodeId.NodeType = nodeType
+	}
+
+	for _, neuron := range cortex.Neurons {
+		if neuron.ActivationFunction == nil {
+			neuron.ActivationFunction = EncodableIdentity()
+		}
+		for _, inbound := range neuron.Inbound {
+			if len(inbound.Weights) == 0 {
+				return fmt.Errorf("connection from %v to %v has no weights", inbound.NodeId.UUID, neuron.NodeId.UUID)
+			}
+		}
+	}
+
+	for _, sensor := range cortex.Sensors {
+		if sensor.VectorLength > 0 {
+			continue
+		}
+		for _, neuron := range cortex.Neurons {
+			if inbound, ok := neuron.InboundUUIDMap()[sensor.NodeId.UUID]; ok {
+				sensor.VectorLength = len(inbound.Weights)
+			}
+		}
+		if sensor.VectorLength == 0 {
+			return fmt.Errorf("cannot work out the vector length of sensor %v", sensor.NodeId.UUID)
+		}
+	}
+	for _, actuator := range cortex.Actuators {
+		if actuator.VectorLength == 0 {
+			actuator.VectorLength = len(actuator.Inbound)
+		}
+	}
+
+	if !cortex.layerIndicesValid() {
+		cortex.NormalizeLayerIndices()
+	}
+
+	cortex.Init()
+	cortex.LinkNodesToCortex()
+	if !cortex.Validate() {
+		return errors.New("cortex still does not Validate() after migrating")
+	}
+	return nil
+}
+
+// Recompute every node's layer index from the connections, treating any
+// connection which closes a loop (searching from the sensors) as recurrent.
+func (cortex *Cortex) NormalizeLayerIndices() {
+
+	// forward edges, derived from the inbound connections since those are
+	// the ones which carry the weights.  They're sorted so that the same
+	// connections are taken to be recurrent every time.
+	outbound := make(map[string][]string)
+	for uuid, inboundNodeIds := range cortex.inboundNodeIds() {
+		for _, inbound := range inboundNodeIds {
+			outbound[inbound.UUID] = append(outbound[inbound.UUID], uuid)
+		}
+	}
+	for _, targets := range outbound {
+		sort.Strings(targets)
+	}
+
+	// depth first search, marking the edges which lead back into the
+	// current path as recurrent
+	recurrent := make(map[string]bool)
+	visited := make(map[string]bool)
+	onPath := make(map[string]bool)
+	var visit func(uuid string)
+	visit = func(uuid string) {
+		visited[uuid] = true
+		onPath[uuid] = true
+		for _, target := range outbound[uuid] {
+			if onPath[target] {
+				recurrent[uuid+" -> "+target] = true
+			} else if !visited[target] {
+				visit(target)
+			}
+		}
+		onPath[uuid] = false
+	}
+	for _, sensor := range cortex.Sensors {
+		visit(sensor.NodeId.UUID)
+	}
+	neuronUUIDs := make([]string, 0)
+	for _, neuron := range cortex.Neurons {
+		neuronUUIDs = append(neuronUUIDs, neuron.NodeId.UUID)
+	}
+	sort.Strings(neuronUUIDs)
+	for _, uuid := range neuronUUIDs {
+		if !visited[uuid] {
+			visit(uuid)
+		}
+	}
+
+	neurons := cortex.NeuronUUIDMap()
+	depths := make(map[string]int)
+	var depth func(uuid string) int
+	depth = func(uuid string) int {
+		if result, ok := depths[uuid]; ok {
+			return result
+		}
+		result := 1
+		for _, inbound := range neurons[uuid].Inbound {
+			if _, ok := neurons[inbound.NodeId.UUID]; !ok || recurrent[inbound.NodeId.UUID+" -> "+uuid] {
+				continue
+			}
+			if inboundDepth := depth(inbound.NodeId.UUID); inboundDepth+1 > result {
+				result = inboundDepth + 1
+			}
+		}
+		depths[uuid] = result
+		return result
+	}
+	maxDepth := 0
+	for _, uuid := range neuronUUIDs {
+		if depth(uuid) > maxDepth {
+			maxDepth = depth(uuid)
+		}
+	}
+
+	layerIndexes := make(map[string]float64)
+	for _, sensor := range cortex.Sensors {
+		layerIndexes[sensor.NodeId.UUID] = 0.0
+	}
+	for _, uuid := range neuronUUIDs {
+		layerIndexes[uuid] = float64(depths[uuid]) / float64(maxDepth+1)
+	}
+	for _, actuator := range cortex.Actuators {
+		layerIndexes[actuator.NodeId.UUID] = 1.0
+	}
+
+	for _, nodeId := range cortex.AllNodeIds() {
+		nodeId.LayerIndex = layerIndexes[nodeId.UUID]
+	}
+	for _, nodeId := range cortex.allNodeIdReferences() {
+		nodeId.LayerIndex = layerIndexes[nodeId.UUID]
+	}
+
+}
+
+// Every neuron must lie in a layer after all of the sensors, and before all
+// of the actuators.
+func (cortex *Cortex) layerIndicesValid() bool {
+	for _, neuron := range cortex.Neurons {
+		for _, sensor := range cortex.Sensors {
+			if sensor.NodeId.LayerIndex >= neuron.NodeId.LayerIndex {
+				return false
+			}
+		}
+		for _, actuator := range cortex.Actuators {
+			if actuator.NodeId.LayerIndex <= neuron.NodeId.LayerIndex {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// The node ids held by connections, which are separate copies of the ones
+// held by the nodes themselves once a cortex has been through json.
+func (cortex *Cortex) allNodeIdReferences() []*NodeId {
+	nodeIds := make([]*NodeId, 0)
+	for _, sensor := range cortex.Sensors {
+		for _, outbound := range sensor.Outbound {
+			nodeIds = append(nodeIds, outbound.NodeId)
+		}
+	}
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			nodeIds = append(nodeIds, inbound.NodeId)
+		}
+		for _, outbound := range neuron.Outbound {
+			nodeIds = append(nodeIds, outbound.NodeId)
+		}
+	}
+	for _, actuator := range cortex.Actuators {
+		for _, inbound := range actuator.Inbound {
+			nodeIds = append(nodeIds, inbound.NodeId)
+		}
+	}
+	return nodeIds
+}
@@ -0,0 +1,72 @@
+package neurgo
+
+import (
+	"encoding/json"
+	"github.com/couchbaselabs/go.assert"
+	"testing"
+)
+
+// Remove the given keys from every object in the decoded json
+func stripJsonKeys(value interface{}, keys ...string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, key := range keys {
+			delete(value, key)
+		}
+		for _, child := range value {
+			stripJsonKeys(child, keys...)
+		}
+	case []interface{}:
+		for _, child := range value {
+			stripJsonKeys(child, keys...)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+
+	// an xnor network with a recurrent connection, saved without layer
+	// indices, node types, activation functions or vector lengths
+	xnorCortex := XnorCortex()
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	outputNeuron := xnorCortex.Neurons[2]
+	outputNeuron.ConnectOutbound(hiddenNeuron1)
+	hiddenNeuron1.ConnectInboundWeighted(outputNeuron, []float64{0.5})
+
+	jsonBytes, err := json.Marshal(xnorCortex)
+	assert.True(t, err == nil)
+	var decoded interface{}
+	json.Unmarshal(jsonBytes, &decoded)
+	stripJsonKeys(decoded, "LayerIndex", "NodeType", "ActivationFunction", "VectorLength")
+	jsonBytes, _ = json.Marshal(decoded)
+
+	migrated := &Cortex{}
+	assert.True(t, json.Unmarshal(jsonBytes, migrated) == nil)
+	assert.True(t, migrated.Migrate() == nil)
+
+	assert.Equals(t, migrated.Sensors[0].VectorLength, 2)
+	assert.Equals(t, migrated.Actuators[0].VectorLength, 1)
+	assert.Equals(t, migrated.Neurons[0].ActivationFunction.Name, "identity")
+	assert.Equals(t, migrated.Neurons[0].NodeId.NodeType, NodeType(NEURON))
+	assert.Equals(t, migrated.Neurons[0].NodeId.LayerIndex, 1.0/3.0)
+	assert.Equals(t, migrated.Neurons[1].NodeId.LayerIndex, 1.0/3.0)
+	assert.Equals(t, migrated.Neurons[2].NodeId.LayerIndex, 2.0/3.0)
+	assert.Equals(t, migrated.Actuators[0].NodeId.LayerIndex, 1.0)
+	assert.Equals(t, len(migrated.Neurons[0].RecurrentInboundConnections()), 1)
+
+	// it runs just like the original would with identity activations
+	for _, neuron := range xnorCortex.Neurons {
+		neuron.ActivationFunction = EncodableIdentity()
+	}
+	assert.True(t, migrated.OutputsMatch(xnorCortex, XnorTrainingSamples(), 1e-9))
+
+	// valid layer indices are left alone
+	xnorCortex = XnorCortex()
+	assert.True(t, xnorCortex.Migrate() == nil)
+	assert.Equals(t, xnorCortex.Neurons[2].NodeId.LayerIndex, 0.35)
+
+	// but a neuron's weights can't be made up
+	xnorCortex.Neurons[2].Inbound[0].Weights = nil
+	assert.True(t, xnorCortex.Migrate() != nil)
+
+}
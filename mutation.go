@@ -0,0 +1,126 @@
+package neurgo
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// How Offspring mutates a copy of its parent
+type MutationConfig struct {
+	WeightProbability  float64 // the chance of each weight being perturbed
+	WeightMagnitude    float64 // the most a weight can be perturbed by
+	AddConnectionCount int     // the number of AddConnection mutations
+}
+
+// A mutated copy of this cortex with a new cortex id and its lineage recorded.
+func (cortex *Cortex) Offspring(config MutationConfig, rng *rand.Rand) *Cortex {
+
+	child := cortex.Copy()
+	child.NodeId = NewCortexId(fmt.Sprintf("cortex-%s", NewUuid()))
+	child.SetLineage(cortex)
+
+	child.perturbWeights(config.WeightProbability, config.WeightMagnitude, rng)
+	for i := 0; i < config.AddConnectionCount; i++ {
+		if _, err := child.addConnection(rng); err != nil {
+			break
+		}
+	}
+
+	return child
+
+}
+
+// Make intensity times the number of neurons structural mutations, then
+// break any cycles that would deadlock the network.
+func (cortex *Cortex) MutateStructure(intensity float64, rng *rand.Rand) {
+
+	operators := []func(rng *rand.Rand) error{
+		cortex.splitRandomNeuron,
+		cortex.mergeRandomNeurons,
+		func(rng *rand.Rand) error {
+			_, err := cortex.addConnection(rng)
+			return err
+		},
+		cortex.removeRandomConnection,
+	}
+
+	mutations := int(intensity*float64(len(cortex.Neurons)) + 0.5)
+	for i := 0; i < mutations; i++ {
+		// if the chosen operator can't be applied, fall back on the others
+		for _, j := range rng.Perm(len(operators)) {
+			if err := operators[j](rng); err == nil {
+				break
+			}
+		}
+	}
+	cortex.BreakNonPrimableCycles()
+
+}
+
+func (cortex *Cortex) splitRandomNeuron(rng *rand.Rand) error {
+	neurons := cortex.SortedNeurons()
+	if len(neurons) == 0 {
+		return errors.New("there are no neurons to split")
+	}
+	cortex.SplitNeuron(neurons[rng.Intn(len(neurons))].NodeId)
+	return nil
+}
+
+// Merge a randomly chosen pair of neurons from the same layer
+func (cortex *Cortex) mergeRandomNeurons(rng *rand.Rand) error {
+	neurons := cortex.SortedNeurons()
+	pairs := make([][]*Neuron, 0)
+	for i, neuron := range neurons {
+		for _, other := range neurons[i+1:] {
+			if other.NodeId.LayerIndex == neuron.NodeId.LayerIndex {
+				pairs = append(pairs, []*Neuron{neuron, other})
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		return errors.New("there are no neurons sharing a layer to merge")
+	}
+	pair := pairs[rng.Intn(len(pairs))]
+	return cortex.MergeNeurons(pair[0].NodeId, pair[1].NodeId)
+}
+
+// Remove a randomly chosen connection into a neuron, as long as it's
+// neither the neuron's only feed forward input nor its sender's only output.
+func (cortex *Cortex) removeRandomConnection(rng *rand.Rand) error {
+
+	type candidate struct {
+		sender OutboundConnector
+		from   *NodeId
+		to     *Neuron
+	}
+
+	candidates := make([]candidate, 0)
+	for _, neuron := range cortex.SortedNeurons() {
+		feedForward := 0
+		for _, inbound := range neuron.Inbound {
+			if !neuron.IsInboundConnectionRecurrent(inbound) {
+				feedForward += 1
+			}
+		}
+		for _, inbound := range neuron.Inbound {
+			if feedForward < 2 && !neuron.IsInboundConnectionRecurrent(inbound) {
+				continue
+			}
+			sender := cortex.FindConnector(inbound.NodeId)
+			if sender == nil || len(sender.outbound()) < 2 {
+				continue
+			}
+			candidates = append(candidates, candidate{sender: sender, from: inbound.NodeId, to: neuron})
+		}
+	}
+	if len(candidates) == 0 {
+		return errors.New("there are no connections which can be removed")
+	}
+
+	chosen := candidates[rng.Intn(len(candidates))]
+	DisconnectOutbound(chosen.sender, chosen.to)
+	DisconnectInbound(chosen.to, chosen.from)
+	return nil
+
+}
@@ -0,0 +1,97 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"math/rand"
+	"testing"
+)
+
+func TestOffspring(t *testing.T) {
+
+	parent := XnorCortex()
+	parentJson := JsonString(parent)
+	config := MutationConfig{
+		WeightProbability:  1.0,
+		WeightMagnitude:    0.5,
+		AddConnectionCount: 2,
+	}
+	child := parent.Offspring(config, rand.New(rand.NewSource(1)))
+
+	// a new member of the next generation
+	assert.True(t, child.NodeId.UUID != parent.NodeId.UUID)
+	assert.Equals(t, child.Generation, parent.Generation+1)
+	assert.Equals(t, child.ParentIds, []string{parent.NodeId.UUID})
+
+	// mutated as configured, leaving the parent alone
+	assert.Equals(t, JsonString(parent), parentJson)
+	assert.Equals(t, child.ConnectionCount(), parent.ConnectionCount()+2)
+	for _, neuron := range parent.Neurons {
+		childNeuron := child.FindNeuron(neuron.NodeId)
+		for _, inbound := range neuron.Inbound {
+			childInbound := childNeuron.InboundUUIDMap()[inbound.NodeId.UUID]
+			assert.False(t, VectorEquals(childInbound.Weights, inbound.Weights))
+		}
+	}
+	assert.True(t, child.Validate())
+
+	// the same rng seed breeds the same child
+	sibling := parent.Offspring(config, rand.New(rand.NewSource(1)))
+	assert.True(t, VectorEquals(sibling.GetParameters(), child.GetParameters()))
+
+	// and with no mutations configured, the child computes the same thing
+	clone := parent.Offspring(MutationConfig{}, rand.New(rand.NewSource(1)))
+	assert.True(t, VectorEquals(clone.GetParameters(), parent.GetParameters()))
+
+}
+
+func TestMutateStructure(t *testing.T) {
+
+	// the number of neurons and connections in one cortex and not the other
+	distance := func(a, b *Cortex) int {
+		structure := func(cortex *Cortex) map[string]bool {
+			elements := make(map[string]bool)
+			for _, neuron := range cortex.Neurons {
+				elements[neuron.NodeId.UUID] = true
+				for _, inbound := range neuron.Inbound {
+					elements[inbound.NodeId.UUID+" -> "+neuron.NodeId.UUID] = true
+				}
+			}
+			return elements
+		}
+		aElements, bElements := structure(a), structure(b)
+		count := 0
+		for element, _ := range aElements {
+			if !bElements[element] {
+				count += 1
+			}
+		}
+		for element, _ := range bElements {
+			if !aElements[element] {
+				count += 1
+			}
+		}
+		return count
+	}
+
+	original := NewFeedForwardCortex([]int{2, 4, 1}, EncodableSigmoid())
+	rng := rand.New(rand.NewSource(1))
+	meanDistance := func(intensity float64) float64 {
+		total := 0
+		for i := 0; i < 20; i++ {
+			mutated := original.Copy()
+			mutated.MutateStructure(intensity, rng)
+			assert.True(t, mutated.Validate())
+			_, err := mutated.Activate([][]float64{[]float64{0, 1}})
+			assert.True(t, err == nil)
+			total += distance(original, mutated)
+		}
+		return float64(total) / 20
+	}
+
+	assert.Equals(t, meanDistance(0), 0.0)
+	low := meanDistance(0.2)
+	high := meanDistance(1)
+	assert.True(t, low > 0)
+	assert.True(t, high > low)
+
+}
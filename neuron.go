@@ -7,10 +7,15 @@ import (
 	"github.com/couchbaselabs/logg"
 	"github.com/proxypoke/vector"
 	"log"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// The integration timestep used by neurons with a TimeConstant
+const CTRNN_TIMESTEP = 1.0
+
 type Neuron struct {
 	NodeId             *NodeId
 	Bias               float64
@@ -19,9 +24,18 @@ type Neuron struct {
 	Closing            chan chan bool
 	DataChan           chan *DataMessage
 	ActivationFunction *EncodableActivation
+	TimeConstant       float64       // see integrateOutput()
+	InputTimeout       time.Duration // see startInputTimeout()
+	Metadata           map[string]string
+	Frozen             bool            // if set, training leaves weights and bias alone
+	BatchNorm          *BatchNormStats // if set, the weighted sum is normalized with it
 	wg                 *sync.WaitGroup
 	Cortex             *Cortex
 	weightedInputs     []*weightedInput
+	state              float64
+	fireCount          int64 // see Cortex.FiringStatus()
+	computeNanos       int64 // see Cortex.NeuronTimings()
+	inference          bool  // see inferenceMode()
 }
 
 func (neuron *Neuron) Init() {
@@ -46,14 +60,20 @@ func (neuron *Neuron) Run() {
 	closed := false
 
 	neuron.checkRunnable()
+	neuron.inference = neuron.Cortex.runInInferenceMode()
 	neuron.createEmptyWeightedInputs()
+	neuron.state = 0
+	atomic.StoreInt64(&neuron.fireCount, 0)
 
 	closed = neuron.primeAllRecurrentOutbound()
+	neuron.Cortex.nodeRunning(neuron.NodeId)
 	if closed {
 		neuron.closeChannels()
 		return
 	}
 
+	var inputTimeout <-chan time.Time
+
 	for {
 		select {
 		case responseChan := <-neuron.Closing:
@@ -62,10 +82,19 @@ func (neuron *Neuron) Run() {
 			break
 		case dataMessage := <-neuron.DataChan:
 			neuron.receiveDataMessage(dataMessage)
-			neuron.logPostReceivedDataMessage(dataMessage)
+			if !neuron.inferenceMode() {
+				neuron.logPostReceivedDataMessage(dataMessage)
+			}
 			if neuron.receiveBarrierSatisfied() {
 				closed = neuron.feedForward()
+				inputTimeout = nil
+			} else if inputTimeout == nil {
+				inputTimeout = neuron.startInputTimeout(dataMessage)
 			}
+		case <-inputTimeout:
+			neuron.receiveMissingInputsAsZero()
+			closed = neuron.feedForward()
+			inputTimeout = nil
 		}
 
 		if closed {
@@ -173,6 +202,50 @@ func (neuron *Neuron) InboundUUIDMap() UUIDToInboundConnection {
 	return inboundUUIDMap
 }
 
+// Summarize the weights on all of this neuron's inbound connections.
+func (neuron *Neuron) WeightStats() (mean, min, max, stddev float64) {
+	weights := make([]float64, 0)
+	for _, connection := range neuron.Inbound {
+		weights = append(weights, connection.Weights...)
+	}
+	if len(weights) == 0 {
+		return
+	}
+	mean = Average(weights)
+	min, max = weights[0], weights[0]
+	for _, weight := range weights {
+		min = math.Min(min, weight)
+		max = math.Max(max, weight)
+	}
+	stddev = math.Sqrt(Variance(weights))
+	return
+}
+
+// The inbound connection contributing the most to the weighted sum, given
+// the inputs keyed by sender UUID, along with its contribution.
+func (neuron *Neuron) DominantInput(sampleInputs map[string][]float64) (*InboundConnection, float64) {
+	var dominant *InboundConnection
+	dominantContribution := float64(0)
+	for _, connection := range neuron.Inbound {
+		inputs, ok := sampleInputs[connection.NodeId.UUID]
+		if !ok {
+			continue
+		}
+		if len(inputs) != len(connection.Weights) {
+			log.Panicf("Expected %v inputs from %v, got %v", len(connection.Weights), connection.NodeId.UUID, len(inputs))
+		}
+		weightedInputs := []*weightedInput{
+			&weightedInput{weights: connection.Weights, inputs: inputs},
+		}
+		contribution := neuron.weightedInputDotProductSum(weightedInputs)
+		if dominant == nil || math.Abs(contribution) > math.Abs(dominantContribution) {
+			dominant = connection
+			dominantContribution = contribution
+		}
+	}
+	return dominant, dominantContribution
+}
+
 func (neuron *Neuron) String() string {
 	return JsonString(neuron)
 }
@@ -185,18 +258,43 @@ func (neuron *Neuron) MarshalJSON() ([]byte, error) {
 			Inbound            []*InboundConnection
 			Outbound           []*OutboundConnection
 			ActivationFunction *EncodableActivation
+			TimeConstant       float64
+			InputTimeout       time.Duration
+			Metadata           map[string]string
+			Frozen             bool
+			BatchNorm          *BatchNormStats
 		}{
 			NodeId:             neuron.NodeId,
 			Bias:               neuron.Bias,
 			Inbound:            neuron.Inbound,
 			Outbound:           neuron.Outbound,
 			ActivationFunction: neuron.ActivationFunction,
+			TimeConstant:       neuron.TimeConstant,
+			InputTimeout:       neuron.InputTimeout,
+			Metadata:           neuron.Metadata,
+			Frozen:             neuron.Frozen,
+			BatchNorm:          neuron.BatchNorm,
 		})
 }
 
 func (neuron *Neuron) feedForward() (closed bool) {
 
-	scalarOutput := neuron.computeScalarOutput(neuron.weightedInputs)
+	inferenceMode := neuron.inferenceMode()
+	profiling := !inferenceMode && neuron.Cortex != nil && neuron.Cortex.Profiling
+	var startTime time.Time
+	if profiling {
+		startTime = time.Now()
+	}
+	weightedSum := neuron.computeWeightedSum(neuron.weightedInputs)
+	scalarOutput := neuron.activate(weightedSum)
+	if profiling {
+		atomic.AddInt64(&neuron.computeNanos, int64(time.Since(startTime)))
+	}
+	scalarOutput = neuron.integrateOutput(scalarOutput)
+	atomic.AddInt64(&neuron.fireCount, 1)
+	if !inferenceMode {
+		neuron.Cortex.neuronFired(neuron, weightedSum, scalarOutput)
+	}
 
 	neuron.weightedInputs = createEmptyWeightedInputs(neuron.Inbound)
 
@@ -212,22 +310,46 @@ func (neuron *Neuron) feedForward() (closed bool) {
 func (neuron *Neuron) scatterOutput(dataMessage *DataMessage) (closed bool) {
 
 	closed = false
+	inferenceMode := neuron.inferenceMode()
 
 	for _, outboundConnection := range neuron.Outbound {
 
+		// once told to close, the rest of the receivers may be gone too
+		if closed {
+			break
+		}
+
 		if outboundConnection.NodeId.UUID == neuron.NodeId.UUID {
 			// if we are sending to ourselves, short-circuit
 			// channel and just call function directly.
 
-			neuron.receiveRecurrentDataMessage(dataMessage)
+			if inferenceMode {
+				neuron.receiveDataMessage(dataMessage)
+			} else {
+				neuron.Cortex.messageSent(neuron.NodeId, neuron.NodeId, dataMessage.Inputs)
+				neuron.receiveRecurrentDataMessage(dataMessage)
+			}
 			if neuron.receiveBarrierSatisfied() {
 				closed = neuron.feedForward()
 			}
 
+		} else if inferenceMode {
+			neuron.Cortex.sending(neuron.NodeId, outboundConnection.NodeId.UUID)
+			select {
+			case responseChan := <-neuron.Closing:
+				closed = true
+				responseChan <- true
+			case outboundConnection.DataChan <- dataMessage:
+			}
+			neuron.Cortex.sending(neuron.NodeId, "")
+
 		} else {
 			logPreSend(neuron.NodeId,
 				outboundConnection.NodeId, dataMessage)
+			neuron.Cortex.messageSent(neuron.NodeId,
+				outboundConnection.NodeId, dataMessage.Inputs)
 
+			neuron.Cortex.sending(neuron.NodeId, outboundConnection.NodeId.UUID)
 			select {
 			case responseChan := <-neuron.Closing:
 				closed = true
@@ -238,6 +360,7 @@ func (neuron *Neuron) scatterOutput(dataMessage *DataMessage) (closed bool) {
 				logPostSend(neuron.NodeId,
 					outboundConnection.NodeId, dataMessage)
 			}
+			neuron.Cortex.sending(neuron.NodeId, "")
 
 		}
 
@@ -287,6 +410,7 @@ func (neuron *Neuron) primeRecurrentOutbound(cxn *OutboundConnection) (closed bo
 			log.Panicf("DataChan is nil for connection: %v", cxn)
 		}
 
+		neuron.Cortex.sending(neuron.NodeId, cxn.NodeId.UUID)
 		select {
 		case cxn.DataChan <- dataMessage:
 		case <-time.After(time.Second):
@@ -295,8 +419,11 @@ func (neuron *Neuron) primeRecurrentOutbound(cxn *OutboundConnection) (closed bo
 			closed = true
 			responseChan <- true
 		}
-		logWeights(neuron)
-		logPostSend(neuron.NodeId, cxn.NodeId, dataMessage)
+		neuron.Cortex.sending(neuron.NodeId, "")
+		if !neuron.inferenceMode() {
+			logWeights(neuron)
+			logPostSend(neuron.NodeId, cxn.NodeId, dataMessage)
+		}
 	}
 
 	return
@@ -313,7 +440,7 @@ func (neuron *Neuron) primeAllRecurrentOutbound() (closed bool) {
 	closed = false
 	recurrentConnections := neuron.RecurrentOutboundConnections()
 	for _, recurrentConnection := range recurrentConnections {
-		closed := neuron.primeRecurrentOutbound(recurrentConnection)
+		closed = neuron.primeRecurrentOutbound(recurrentConnection)
 		if closed {
 			break
 		}
@@ -366,18 +493,53 @@ func (neuron *Neuron) validateOutbound() error {
 }
 
 func (neuron *Neuron) computeScalarOutput(weightedInputs []*weightedInput) float64 {
+	return neuron.activate(neuron.computeWeightedSum(weightedInputs))
+}
+
+// the weighted inputs plus bias, ie the input to the activation function
+func (neuron *Neuron) computeWeightedSum(weightedInputs []*weightedInput) float64 {
+	if neuron.inferenceMode() {
+		return neuron.batchNormalize(neuron.fastWeightedSum(weightedInputs))
+	}
 	output := neuron.weightedInputDotProductSum(weightedInputs)
 	logmsg := fmt.Sprintf("%v raw output: %v", neuron.NodeId.UUID, output)
 	logg.LogTo("NODE_STATE", logmsg)
 	output += neuron.Bias
 	logmsg = fmt.Sprintf("%v raw output + bias: %v", neuron.NodeId.UUID, output)
 	logg.LogTo("NODE_STATE", logmsg)
-	output = neuron.ActivationFunction.ActivationFunction(output)
-	logmsg = fmt.Sprintf("%v after activation: %v", neuron.NodeId.UUID, output)
+	if neuron.BatchNorm != nil {
+		output = neuron.batchNormalize(output)
+		logmsg = fmt.Sprintf("%v after batch norm: %v", neuron.NodeId.UUID, output)
+		logg.LogTo("NODE_STATE", logmsg)
+	}
+	return output
+}
+
+func (neuron *Neuron) activate(weightedSum float64) float64 {
+	output := neuron.ActivationFunction.ActivationFunction(weightedSum)
+	if neuron.inferenceMode() {
+		return output
+	}
+	logmsg := fmt.Sprintf("%v after activation: %v", neuron.NodeId.UUID, output)
 	logg.LogTo("NODE_STATE", logmsg)
 	return output
 }
 
+// With a TimeConstant, integrate towards the activated value over a single
+// CTRNN_TIMESTEP, like a continuous time recurrent neuron.
+func (neuron *Neuron) integrateOutput(activated float64) float64 {
+	if neuron.TimeConstant == 0 {
+		return activated
+	}
+	delta := (-1*neuron.state + activated) / neuron.TimeConstant
+	neuron.state += CTRNN_TIMESTEP * delta
+	if !neuron.inferenceMode() {
+		logmsg := fmt.Sprintf("%v after integration: %v", neuron.NodeId.UUID, neuron.state)
+		logg.LogTo("NODE_STATE", logmsg)
+	}
+	return neuron.state
+}
+
 // for each weighted input vector, calculate the (inputs * weights) dot product
 // and sum all of these dot products together to produce a sum
 func (neuron *Neuron) weightedInputDotProductSum(weightedInputs []*weightedInput) float64 {
@@ -386,6 +548,9 @@ func (neuron *Neuron) weightedInputDotProductSum(weightedInputs []*weightedInput
 	dotProductSummation = 0
 
 	for _, weightedInput := range weightedInputs {
+		if weightedInput.disabled {
+			continue
+		}
 		inputs := weightedInput.inputs
 		weights := weightedInput.weights
 		inputVector := vector.NewFrom(inputs)
@@ -403,6 +568,36 @@ func (neuron *Neuron) weightedInputDotProductSum(weightedInputs []*weightedInput
 
 }
 
+// Same as computeWeightedSum, but without any logging or allocations, for
+// when the cortex is in InferenceMode.
+func (neuron *Neuron) fastWeightedSum(weightedInputs []*weightedInput) float64 {
+	sum := 0.0
+	for _, weightedInput := range weightedInputs {
+		if weightedInput.disabled {
+			continue
+		}
+		inputs := weightedInput.inputs
+		weights := weightedInput.weights
+		if len(inputs) != len(weights) {
+			t := "%T error performing dot product between %v and %v"
+			message := fmt.Sprintf(t, neuron, inputs, weights)
+			panic(message)
+		}
+		dotProduct := 0.0
+		for i, input := range inputs {
+			dotProduct += input * weights[i]
+		}
+		sum += dotProduct
+	}
+	return sum + neuron.Bias
+}
+
+// Whether the neuron was launched in InferenceMode, which is fixed for the
+// whole run (see Cortex.runInInferenceMode()).
+func (neuron *Neuron) inferenceMode() bool {
+	return neuron.inference
+}
+
 func (neuron *Neuron) dataChan() chan *DataMessage {
 	return neuron.DataChan
 }
@@ -432,6 +627,36 @@ func (neuron *Neuron) receiveBarrierSatisfied() bool {
 	return receiveBarrierSatisfied(neuron.weightedInputs)
 }
 
+// Start the InputTimeout timer, if any, once the first feed forward input of
+// a pass arrives.  Otherwise returns nil, which never fires.
+func (neuron *Neuron) startInputTimeout(dataMessage *DataMessage) <-chan time.Time {
+	if neuron.InputTimeout <= 0 {
+		return nil
+	}
+	for _, inbound := range neuron.Inbound {
+		if inbound.NodeId.UUID != dataMessage.SenderId.UUID {
+			continue
+		}
+		if !neuron.IsInboundConnectionRecurrent(inbound) {
+			return time.After(neuron.InputTimeout)
+		}
+	}
+	return nil
+}
+
+// Treat any inputs which haven't arrived yet as zeros
+func (neuron *Neuron) receiveMissingInputsAsZero() {
+	for _, weightedInput := range neuron.weightedInputs {
+		if weightedInput.inputs == nil {
+			if !neuron.inferenceMode() {
+				logmsg := fmt.Sprintf("%v timed out waiting on %v", neuron.NodeId.UUID, weightedInput.senderNodeUUID)
+				logg.LogTo("NODE_STATE", logmsg)
+			}
+			weightedInput.inputs = make([]float64, len(weightedInput.weights))
+		}
+	}
+}
+
 func (neuron *Neuron) receiveDataMessage(dataMessage *DataMessage) {
 
 	recordInput(neuron.weightedInputs, dataMessage)
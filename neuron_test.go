@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/couchbaselabs/go.assert"
 	"log"
+	"math"
 	"testing"
 	"time"
 )
@@ -36,6 +37,31 @@ func TestNeuronJsonMarshal(t *testing.T) {
 	log.Printf("jsonString: %v", jsonString)
 }
 
+func TestNeuronMetadata(t *testing.T) {
+
+	neuron := &Neuron{
+		ActivationFunction: EncodableSigmoid(),
+		NodeId:             NewNeuronId("neuron", 0.25),
+		Metadata: map[string]string{
+			"origin":     "add-neuron",
+			"generation": "7",
+		},
+	}
+
+	neuronCopy := neuron.Copy()
+	assert.Equals(t, neuronCopy.Metadata, neuron.Metadata)
+
+	// the copy has its own map
+	neuronCopy.Metadata["origin"] = "split-neuron"
+	assert.Equals(t, neuron.Metadata["origin"], "add-neuron")
+
+	// and a whole cortex copy keeps it too
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[0].Metadata = map[string]string{"note": "hidden"}
+	assert.Equals(t, xnorCortex.Copy().Neurons[0].Metadata["note"], "hidden")
+
+}
+
 func TestRecurrentNeuron(t *testing.T) {
 
 	// injector -> n1 -> n2 -> wiretap where n2 has recurrent connection
@@ -307,6 +333,121 @@ func TestRunningNeuron(t *testing.T) {
 
 }
 
+func TestNeuronInputTimeout(t *testing.T) {
+
+	nodeId_1 := NewSensorId("node-1", 0.0)
+	nodeId_2 := NewSensorId("node-2", 0.0)
+
+	wiretapDataChan := make(chan *DataMessage, 1)
+	wiretapConnection := &OutboundConnection{
+		NodeId:   NewActuatorId("wiretap-node", 0.5),
+		DataChan: wiretapDataChan,
+	}
+
+	neuron := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("neuron", 0.25),
+		Bias:               1,
+		Inbound: []*InboundConnection{
+			&InboundConnection{NodeId: nodeId_1, Weights: []float64{2}},
+			&InboundConnection{NodeId: nodeId_2, Weights: []float64{3}},
+		},
+		Outbound:     []*OutboundConnection{wiretapConnection},
+		InputTimeout: time.Second / 10,
+	}
+	neuron.Init()
+	go neuron.Run()
+
+	// only one of the two inputs ever arrives, so after the timeout the
+	// neuron fires with the other one treated as zero
+	neuron.DataChan <- &DataMessage{SenderId: nodeId_1, Inputs: []float64{5}}
+	select {
+	case outputDataMessage := <-wiretapDataChan:
+		assert.Equals(t, outputDataMessage.Inputs, []float64{11})
+	case <-time.After(time.Second):
+		assert.Errorf(t, "Timed out waiting for output")
+	}
+
+	// with every input arriving, it fires as usual
+	neuron.DataChan <- &DataMessage{SenderId: nodeId_1, Inputs: []float64{1}}
+	neuron.DataChan <- &DataMessage{SenderId: nodeId_2, Inputs: []float64{1}}
+	select {
+	case outputDataMessage := <-wiretapDataChan:
+		assert.Equals(t, outputDataMessage.Inputs, []float64{6})
+	case <-time.After(time.Second):
+		assert.Errorf(t, "Timed out waiting for output")
+	}
+
+	// and never fires without having received anything at all
+	select {
+	case output := <-wiretapDataChan:
+		assert.Errorf(t, "Got unexpected output: %v", output)
+	case <-time.After(time.Second / 4):
+	}
+
+	neuron.Shutdown()
+
+}
+
+func TestNeuronInputTimeoutRecurrent(t *testing.T) {
+
+	sensorNodeId := NewSensorId("sensor", 0.0)
+	neuronNodeId := NewNeuronId("neuron", 0.25)
+
+	wiretapDataChan := make(chan *DataMessage, 1)
+	wiretapConnection := &OutboundConnection{
+		NodeId:   NewActuatorId("wiretap-node", 0.5),
+		DataChan: wiretapDataChan,
+	}
+
+	neuron := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             neuronNodeId,
+		Bias:               1,
+		Inbound: []*InboundConnection{
+			&InboundConnection{NodeId: sensorNodeId, Weights: []float64{2}},
+			&InboundConnection{NodeId: neuronNodeId, Weights: []float64{1}},
+		},
+		Outbound: []*OutboundConnection{
+			&OutboundConnection{NodeId: neuronNodeId},
+			wiretapConnection,
+		},
+		InputTimeout: time.Second / 10,
+	}
+	neuron.Init()
+	neuron.Outbound[0].DataChan = neuron.DataChan
+	go neuron.Run()
+
+	// the recurrent input is there from the start, and again after every
+	// pass, but it's the sensor input which starts a pass, so the neuron
+	// never fires while it's waiting between passes
+	expectNoOutput := func() {
+		select {
+		case output := <-wiretapDataChan:
+			assert.Errorf(t, "Got unexpected output: %v", output)
+		case <-time.After(time.Second / 4):
+		}
+	}
+	expectOutput := func(expected float64) {
+		select {
+		case outputDataMessage := <-wiretapDataChan:
+			assert.Equals(t, outputDataMessage.Inputs, []float64{expected})
+		case <-time.After(time.Second):
+			assert.Errorf(t, "Timed out waiting for output")
+		}
+	}
+
+	expectNoOutput()
+	neuron.DataChan <- &DataMessage{SenderId: sensorNodeId, Inputs: []float64{5}}
+	expectOutput(11)
+	expectNoOutput()
+	neuron.DataChan <- &DataMessage{SenderId: sensorNodeId, Inputs: []float64{1}}
+	expectOutput(14)
+
+	neuron.Shutdown()
+
+}
+
 func TestComputeScalarOutput(t *testing.T) {
 
 	activation := encodableIdentityActivationFunction()
@@ -341,6 +482,63 @@ func TestComputeScalarOutput(t *testing.T) {
 
 }
 
+func TestNeuronWeightStats(t *testing.T) {
+
+	neuron := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("neuron", 0.25),
+	}
+
+	mean, min, max, stddev := neuron.WeightStats()
+	assert.Equals(t, []float64{mean, min, max, stddev}, []float64{0, 0, 0, 0})
+
+	neuron.Inbound = []*InboundConnection{
+		&InboundConnection{NodeId: NewSensorId("sensor", 0.0), Weights: []float64{1, 2}},
+		&InboundConnection{NodeId: NewNeuronId("neuron1", 0.0), Weights: []float64{3}},
+		&InboundConnection{NodeId: NewNeuronId("neuron2", 0.0), Weights: []float64{-2}},
+	}
+
+	// deviations from the mean of 1 are 0, 1, 2, -3
+	mean, min, max, stddev = neuron.WeightStats()
+	assert.Equals(t, mean, 1.0)
+	assert.Equals(t, min, -2.0)
+	assert.Equals(t, max, 3.0)
+	assert.Equals(t, stddev, math.Sqrt(14.0/4.0))
+
+}
+
+func TestNeuronDominantInput(t *testing.T) {
+
+	neuron := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("neuron", 0.25),
+	}
+	neuron.Inbound = []*InboundConnection{
+		&InboundConnection{NodeId: NewSensorId("sensor", 0.0), Weights: []float64{1, 2}},
+		&InboundConnection{NodeId: NewNeuronId("neuron1", 0.0), Weights: []float64{-10}},
+		&InboundConnection{NodeId: NewNeuronId("neuron2", 0.0), Weights: []float64{2}},
+	}
+
+	sampleInputs := map[string][]float64{
+		"sensor":  []float64{1, 1},
+		"neuron1": []float64{0.5},
+		"neuron2": []float64{1},
+	}
+	connection, contribution := neuron.DominantInput(sampleInputs)
+	assert.Equals(t, connection.NodeId.UUID, "neuron1")
+	assert.Equals(t, contribution, -5.0)
+
+	// with neuron1 quiet, the sensor dominates
+	sampleInputs["neuron1"] = []float64{0}
+	connection, contribution = neuron.DominantInput(sampleInputs)
+	assert.Equals(t, connection.NodeId.UUID, "sensor")
+	assert.Equals(t, contribution, 3.0)
+
+	connection, _ = neuron.DominantInput(map[string][]float64{})
+	assert.True(t, connection == nil)
+
+}
+
 func TestNeuronShutdown(t *testing.T) {
 
 	sensor := &Sensor{
@@ -373,6 +571,68 @@ func TestNeuronShutdown(t *testing.T) {
 
 }
 
+func TestNeuronShutdownWhileSending(t *testing.T) {
+
+	sensorNodeId := NewSensorId("sensor", 0.0)
+
+	// neither receiver ever reads, eg because they've already shut down
+	neuron := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("neuron", 0.25),
+		Inbound: []*InboundConnection{
+			&InboundConnection{NodeId: sensorNodeId, Weights: []float64{1}},
+		},
+		Outbound: []*OutboundConnection{
+			&OutboundConnection{NodeId: NewNeuronId("neuron-1", 0.5), DataChan: make(chan *DataMessage)},
+			&OutboundConnection{NodeId: NewNeuronId("neuron-2", 0.5), DataChan: make(chan *DataMessage)},
+		},
+	}
+	neuron.Init()
+	go neuron.Run()
+	neuron.DataChan <- &DataMessage{SenderId: sensorNodeId, Inputs: []float64{1}}
+
+	shutdown := make(chan bool)
+	go func() {
+		neuron.Shutdown()
+		shutdown <- true
+	}()
+	select {
+	case <-shutdown:
+	case <-time.After(time.Second):
+		assert.Errorf(t, "Timed out waiting for the neuron to shut down")
+	}
+
+}
+
+func TestNeuronShutdownWhilePriming(t *testing.T) {
+
+	// the neuron it has to prime never reads
+	neuron := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("neuron", 0.5),
+		Inbound: []*InboundConnection{
+			&InboundConnection{NodeId: NewSensorId("sensor", 0.0), Weights: []float64{1}},
+		},
+		Outbound: []*OutboundConnection{
+			&OutboundConnection{NodeId: NewNeuronId("neuron-1", 0.25), DataChan: make(chan *DataMessage)},
+		},
+	}
+	neuron.Init()
+	go neuron.Run()
+
+	shutdown := make(chan bool)
+	go func() {
+		neuron.Shutdown()
+		shutdown <- true
+	}()
+	select {
+	case <-shutdown:
+	case <-time.After(time.Second / 2):
+		assert.Errorf(t, "Timed out waiting for the neuron to shut down")
+	}
+
+}
+
 func TestRecurrentOutboundConnections(t *testing.T) {
 
 	// make a recurrent connection
@@ -412,3 +672,47 @@ func TestRecurrentOutboundConnections(t *testing.T) {
 	assert.Equals(t, len(recurrentConnections), 1)
 
 }
+
+func TestNeuronTimeConstant(t *testing.T) {
+
+	sensorNodeId := NewSensorId("sensor", 0.0)
+
+	wiretapDataChan := make(chan *DataMessage, 1)
+	wiretapConnection := &OutboundConnection{
+		NodeId:   NewActuatorId("wiretap-node", 0.5),
+		DataChan: wiretapDataChan,
+	}
+
+	neuron := &Neuron{
+		ActivationFunction: encodableIdentityActivationFunction(),
+		NodeId:             NewNeuronId("neuron", 0.25),
+		Bias:               0,
+		Inbound: []*InboundConnection{
+			&InboundConnection{NodeId: sensorNodeId, Weights: []float64{1}},
+		},
+		Outbound:     []*OutboundConnection{wiretapConnection},
+		TimeConstant: 2,
+	}
+	neuron.Init()
+	go neuron.Run()
+
+	// with a constant input of 10, the output should close half the
+	// remaining distance to 10 on each pass, rather than jumping there
+	expectedOutputs := []float64{5, 7.5, 8.75, 9.375}
+	for _, expectedOutput := range expectedOutputs {
+		neuron.DataChan <- &DataMessage{
+			SenderId: sensorNodeId,
+			Inputs:   []float64{10},
+		}
+		select {
+		case outputDataMessage := <-wiretapDataChan:
+			output := outputDataMessage.Inputs[0]
+			assert.True(t, EqualsWithMaxDelta(output, expectedOutput, 1e-9))
+		case <-time.After(time.Second):
+			assert.Errorf(t, "Timed out waiting for output")
+		}
+	}
+
+	neuron.Shutdown()
+
+}
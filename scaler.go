@@ -0,0 +1,123 @@
+package neurgo
+
+import (
+	"log"
+)
+
+// Rescales inputs so that every element lies between 0 and 1 over the
+// samples it was fitted on.  Min and Max are indexed by sensor, then element.
+type FeatureScaler struct {
+	Min [][]float64
+	Max [][]float64
+}
+
+// Record the smallest and largest value of each input element across the
+// samples, replacing anything fitted before.
+func (scaler *FeatureScaler) Fit(samples []*TrainingSample) {
+
+	if len(samples) == 0 {
+		log.Panicf("Cannot fit a FeatureScaler without any samples")
+	}
+
+	scaler.Min = make([][]float64, len(samples[0].SampleInputs))
+	scaler.Max = make([][]float64, len(samples[0].SampleInputs))
+	for i, inputs := range samples[0].SampleInputs {
+		scaler.Min[i] = make([]float64, len(inputs))
+		scaler.Max[i] = make([]float64, len(inputs))
+		copy(scaler.Min[i], inputs)
+		copy(scaler.Max[i], inputs)
+	}
+
+	for _, sample := range samples[1:] {
+		scaler.checkShape(sample.SampleInputs)
+		for i, inputs := range sample.SampleInputs {
+			for j, input := range inputs {
+				if input < scaler.Min[i][j] {
+					scaler.Min[i][j] = input
+				}
+				if input > scaler.Max[i][j] {
+					scaler.Max[i][j] = input
+				}
+			}
+		}
+	}
+
+}
+
+// Scale the inputs (one vector per sensor) into the fitted range.
+func (scaler *FeatureScaler) Transform(inputs [][]float64) [][]float64 {
+	scaler.checkShape(inputs)
+	transformed := make([][]float64, len(inputs))
+	for i, vector := range inputs {
+		transformed[i] = make([]float64, len(vector))
+		for j, input := range vector {
+			transformed[i][j] = scaler.scale(i, j, input)
+		}
+	}
+	return transformed
+}
+
+// Scale a single input, element j of sensor i's input vector
+func (scaler *FeatureScaler) scale(i, j int, input float64) float64 {
+	width := scaler.Max[i][j] - scaler.Min[i][j]
+	if width == 0 {
+		return 0
+	}
+	return (input - scaler.Min[i][j]) / width
+}
+
+// Undo Transform, mapping scaled values back to the original range.
+func (scaler *FeatureScaler) InverseTransform(inputs [][]float64) [][]float64 {
+	scaler.checkShape(inputs)
+	original := make([][]float64, len(inputs))
+	for i, vector := range inputs {
+		original[i] = make([]float64, len(vector))
+		for j, input := range vector {
+			width := scaler.Max[i][j] - scaler.Min[i][j]
+			original[i][j] = input*width + scaler.Min[i][j]
+		}
+	}
+	return original
+}
+
+// The derivative of Transform for element j of sensor i's input vector.
+func (scaler *FeatureScaler) slope(i, j int) float64 {
+	width := scaler.Max[i][j] - scaler.Min[i][j]
+	if width == 0 {
+		return 0
+	}
+	return 1 / width
+}
+
+// Was the scaler fitted on inputs the shape of these sensors' inputs?
+func (scaler *FeatureScaler) fits(sensors []*Sensor) bool {
+	if len(scaler.Min) != len(sensors) {
+		return false
+	}
+	for i, sensor := range sensors {
+		if len(scaler.Min[i]) != sensor.VectorLength {
+			return false
+		}
+	}
+	return true
+}
+
+func (scaler *FeatureScaler) checkShape(inputs [][]float64) {
+	if len(inputs) != len(scaler.Min) {
+		log.Panicf("Got %d input vectors, scaler was fitted on %d", len(inputs), len(scaler.Min))
+	}
+	for i, vector := range inputs {
+		if len(vector) != len(scaler.Min[i]) {
+			log.Panicf("Input vector %v has length %d, scaler was fitted on %d", vector, len(vector), len(scaler.Min[i]))
+		}
+	}
+}
+
+// The inputs (one vector per sensor) as the sensors should see them: scaled
+// by the cortex's Scaler if it has one, otherwise unchanged.
+func (cortex *Cortex) scaleInputs(inputs [][]float64) [][]float64 {
+	if cortex.Scaler == nil {
+		return inputs
+	}
+	return cortex.Scaler.Transform(inputs)
+}
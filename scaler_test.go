@@ -0,0 +1,70 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"testing"
+)
+
+func TestFeatureScaler(t *testing.T) {
+
+	samples := []*TrainingSample{
+		{SampleInputs: [][]float64{[]float64{-10, 100, 5}}},
+		{SampleInputs: [][]float64{[]float64{10, 300, 5}}},
+		{SampleInputs: [][]float64{[]float64{0, 200, 5}}},
+	}
+
+	scaler := &FeatureScaler{}
+	scaler.Fit(samples)
+	assert.Equals(t, scaler.Min, [][]float64{[]float64{-10, 100, 5}})
+	assert.Equals(t, scaler.Max, [][]float64{[]float64{10, 300, 5}})
+
+	for _, sample := range samples {
+		transformed := scaler.Transform(sample.SampleInputs)
+		for _, value := range transformed[0] {
+			assert.True(t, value >= 0 && value <= 1)
+		}
+		original := scaler.InverseTransform(transformed)
+		assert.True(t, vectorEqualsWithMaxDelta(original[0][0:2], sample.SampleInputs[0][0:2], 1e-12))
+	}
+	assert.Equals(t, scaler.Transform(samples[2].SampleInputs), [][]float64{[]float64{0.5, 0.5, 0}})
+
+}
+
+func TestCortexScaler(t *testing.T) {
+
+	// the same as the xnor samples, but with inputs of 0 or 10
+	samples := XnorTrainingSamples()
+	for _, sample := range samples {
+		for j, _ := range sample.SampleInputs[0] {
+			sample.SampleInputs[0][j] *= 10
+		}
+	}
+
+	xnorCortex := XnorCortex()
+	xnorCortex.Scaler = &FeatureScaler{}
+	xnorCortex.Scaler.Fit(samples)
+
+	for _, sample := range samples {
+		outputs, err := xnorCortex.Activate(sample.SampleInputs)
+		assert.True(t, err == nil)
+		assert.True(t, EqualsWithMaxDelta(outputs[0][0], sample.ExpectedOutputs[0][0], 0.01))
+	}
+
+	// every other way of evaluating the network scales the inputs too
+	fitness, predictions := xnorCortex.EvaluateWithPredictions(samples)
+	assert.True(t, xnorCortex.Verify(samples))
+	assert.True(t, EqualsWithMaxDelta(xnorCortex.Fitness(samples), fitness, 1e-9*fitness))
+	neurons := xnorCortex.SortedNeurons()
+	forwardPassError := 0.0
+	for i, sample := range samples {
+		outputs, _ := xnorCortex.Activate(sample.SampleInputs)
+		assert.Equals(t, predictions[i], outputs)
+		forwardPassOutputs, _ := xnorCortex.forwardPass(neurons, sample.SampleInputs)
+		forwardPassError += xnorCortex.forwardPassError(forwardPassOutputs, sample)
+	}
+	assert.True(t, EqualsWithMaxDelta(float64(1)/forwardPassError, fitness, 1e-9*fitness))
+
+	// the scaler is saved along with the cortex
+	assert.Equals(t, xnorCortex.Copy().Scaler.Max, xnorCortex.Scaler.Max)
+
+}
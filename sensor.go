@@ -20,6 +20,7 @@ type Sensor struct {
 	SensorFunction SensorFunction
 	wg             *sync.WaitGroup
 	Cortex         *Cortex
+	inference      bool // see Cortex.runInInferenceMode()
 }
 
 func (sensor *Sensor) Init() {
@@ -52,6 +53,8 @@ func (sensor *Sensor) Run() {
 	defer sensor.wg.Done()
 
 	sensor.checkRunnable()
+	sensor.inference = sensor.Cortex.runInInferenceMode()
+	sensor.Cortex.nodeRunning(sensor.NodeId)
 
 	closed := false
 	syncCounter := 0
@@ -71,7 +74,7 @@ func (sensor *Sensor) Run() {
 				SenderId: sensor.NodeId,
 				Inputs:   input,
 			}
-			sensor.scatterOutput(dataMessage)
+			closed = sensor.scatterOutput(dataMessage)
 		}
 
 		if closed {
@@ -165,20 +168,37 @@ func (sensor *Sensor) validateOutbound() error {
 	return nil
 }
 
-func (sensor *Sensor) scatterOutput(dataMessage *DataMessage) {
+// Send the message to every outbound connection, unless the sensor is
+// told to close while it's waiting for one of the receivers.
+func (sensor *Sensor) scatterOutput(dataMessage *DataMessage) (closed bool) {
 
 	if len(dataMessage.Inputs) == 0 {
 		logg.LogPanic("cannot scatter empty data message")
 	}
 
+	inferenceMode := sensor.inference
+
 	for _, outboundConnection := range sensor.Outbound {
-		logmsg := fmt.Sprintf("%v -> %v: %v", sensor.NodeId.UUID,
-			outboundConnection.NodeId.UUID, dataMessage)
-		logg.LogTo("NODE_PRE_SEND", logmsg)
-		dataChan := outboundConnection.DataChan
-		dataChan <- dataMessage
-		logg.LogTo("NODE_POST_SEND", logmsg)
+		logmsg := ""
+		if !inferenceMode {
+			logmsg = fmt.Sprintf("%v -> %v: %v", sensor.NodeId.UUID,
+				outboundConnection.NodeId.UUID, dataMessage)
+			logg.LogTo("NODE_PRE_SEND", logmsg)
+			sensor.Cortex.messageSent(sensor.NodeId, outboundConnection.NodeId, dataMessage.Inputs)
+		}
+		sensor.Cortex.sending(sensor.NodeId, outboundConnection.NodeId.UUID)
+		select {
+		case responseChan := <-sensor.Closing:
+			responseChan <- true
+			return true
+		case outboundConnection.DataChan <- dataMessage:
+		}
+		sensor.Cortex.sending(sensor.NodeId, "")
+		if !inferenceMode {
+			logg.LogTo("NODE_POST_SEND", logmsg)
+		}
 	}
+	return false
 }
 
 func (sensor *Sensor) nodeId() *NodeId {
@@ -0,0 +1,924 @@
+package neurgo
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Map each sensor and neuron UUID to the nodes it sends its output to
+func (cortex *Cortex) outboundNodeIds() map[string][]*NodeId {
+	outboundNodeIds := make(map[string][]*NodeId)
+	for _, sensor := range cortex.Sensors {
+		for _, outbound := range sensor.Outbound {
+			outboundNodeIds[sensor.NodeId.UUID] = append(outboundNodeIds[sensor.NodeId.UUID], outbound.NodeId)
+		}
+	}
+	for _, neuron := range cortex.Neurons {
+		for _, outbound := range neuron.Outbound {
+			outboundNodeIds[neuron.NodeId.UUID] = append(outboundNodeIds[neuron.NodeId.UUID], outbound.NodeId)
+		}
+	}
+	return outboundNodeIds
+}
+
+// Map each neuron and actuator UUID to the nodes it receives input from
+func (cortex *Cortex) inboundNodeIds() map[string][]*NodeId {
+	inboundNodeIds := make(map[string][]*NodeId)
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			inboundNodeIds[neuron.NodeId.UUID] = append(inboundNodeIds[neuron.NodeId.UUID], inbound.NodeId)
+		}
+	}
+	for _, actuator := range cortex.Actuators {
+		for _, inbound := range actuator.Inbound {
+			inboundNodeIds[actuator.NodeId.UUID] = append(inboundNodeIds[actuator.NodeId.UUID], inbound.NodeId)
+		}
+	}
+	return inboundNodeIds
+}
+
+// Find the UUIDs of every node reachable from the given node by following
+// the edges, including the node itself.
+func reachableUUIDs(uuid string, edges map[string][]*NodeId) map[string]bool {
+	reached := map[string]bool{uuid: true}
+	pending := []string{uuid}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		for _, nodeId := range edges[current] {
+			if !reached[nodeId.UUID] {
+				reached[nodeId.UUID] = true
+				pending = append(pending, nodeId.UUID)
+			}
+		}
+	}
+	return reached
+}
+
+func (cortex *Cortex) findNodeId(nodeId *NodeId) *NodeId {
+	for _, candidate := range cortex.AllNodeIds() {
+		if candidate.UUID == nodeId.UUID {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// Extract the part of the network on paths from fromId to toId as a new
+// cortex, with sensors standing in for any inputs from outside of it.
+func (cortex *Cortex) Subgraph(fromId, toId *NodeId) (*Cortex, error) {
+
+	from := cortex.findNodeId(fromId)
+	if from == nil || (from.NodeType != SENSOR && from.NodeType != NEURON) {
+		return nil, fmt.Errorf("%v is not a sensor or neuron in this cortex", fromId)
+	}
+	to := cortex.findNodeId(toId)
+	if to == nil || (to.NodeType != NEURON && to.NodeType != ACTUATOR) {
+		return nil, fmt.Errorf("%v is not a neuron or actuator in this cortex", toId)
+	}
+
+	downstream := reachableUUIDs(from.UUID, cortex.outboundNodeIds())
+	upstream := reachableUUIDs(to.UUID, cortex.inboundNodeIds())
+	if !downstream[to.UUID] {
+		return nil, fmt.Errorf("there is no path from %v to %v", from.UUID, to.UUID)
+	}
+	onPath := func(uuid string) bool {
+		return downstream[uuid] && upstream[uuid]
+	}
+
+	// work on a copy so the original is left untouched
+	cortexCopy := cortex.Copy()
+
+	neurons := make([]*Neuron, 0)
+	sensors := make([]*Sensor, 0)
+	boundarySensors := make(map[string]*Sensor)
+
+	for _, neuron := range cortexCopy.Neurons {
+		if !onPath(neuron.NodeId.UUID) {
+			continue
+		}
+		neuron.Cortex = nil
+
+		// any input from outside the subgraph becomes a sensor
+		for _, inbound := range neuron.Inbound {
+			if onPath(inbound.NodeId.UUID) && inbound.NodeId.NodeType != SENSOR {
+				continue
+			}
+			sensor, ok := boundarySensors[inbound.NodeId.UUID]
+			if !ok {
+				sensor = &Sensor{
+					NodeId:       NewSensorId(inbound.NodeId.UUID, inbound.NodeId.LayerIndex),
+					VectorLength: len(inbound.Weights),
+				}
+				boundarySensors[inbound.NodeId.UUID] = sensor
+				sensors = append(sensors, sensor)
+			}
+			inbound.NodeId = sensor.NodeId
+			sensor.Outbound = append(sensor.Outbound, &OutboundConnection{
+				NodeId: neuron.NodeId,
+			})
+		}
+
+		// drop any output to outside the subgraph
+		outbound := make([]*OutboundConnection, 0)
+		for _, connection := range neuron.Outbound {
+			if onPath(connection.NodeId.UUID) {
+				outbound = append(outbound, &OutboundConnection{
+					NodeId: connection.NodeId,
+				})
+			}
+		}
+		neuron.Outbound = outbound
+
+		neurons = append(neurons, neuron)
+	}
+
+	var actuator *Actuator
+	if to.NodeType == ACTUATOR {
+		actuator = cortexCopy.FindActuator(to)
+		actuator.Cortex = nil
+		inbound := make([]*InboundConnection, 0)
+		for _, connection := range actuator.Inbound {
+			if onPath(connection.NodeId.UUID) {
+				inbound = append(inbound, connection)
+			}
+		}
+		actuator.Inbound = inbound
+		actuator.VectorLength = len(inbound)
+	} else {
+		layerIndexes := cortex.NodeIdLayerMap().Keys()
+		actuatorLayerIndex := layerIndexes[len(layerIndexes)-1]
+		if actuatorLayerIndex <= to.LayerIndex {
+			actuatorLayerIndex = to.LayerIndex + 1
+		}
+		actuator = &Actuator{
+			NodeId:       NewActuatorId(NewUuid(), actuatorLayerIndex),
+			VectorLength: 1,
+		}
+		toNeuron := cortexCopy.FindNeuron(to)
+		toNeuron.Outbound = append(toNeuron.Outbound, &OutboundConnection{
+			NodeId: actuator.NodeId,
+		})
+		actuator.Inbound = []*InboundConnection{
+			&InboundConnection{NodeId: toNeuron.NodeId},
+		}
+	}
+
+	subgraph := &Cortex{
+		NodeId: NewCortexId(NewUuid()),
+	}
+	subgraph.SetSensors(sensors)
+	subgraph.SetNeurons(neurons)
+	subgraph.SetActuators([]*Actuator{actuator})
+
+	return subgraph, nil
+
+}
+
+// The first recurrent outbound connection between each pair of nodes.
+func (cortex *Cortex) RecurrentConnections() []*OutboundConnection {
+	result := make([]*OutboundConnection, 0)
+	seen := make(map[[2]string]bool)
+	for _, neuron := range cortex.Neurons {
+		for _, outbound := range neuron.RecurrentOutboundConnections() {
+			pair := [2]string{neuron.NodeId.UUID, outbound.NodeId.UUID}
+			if seen[pair] {
+				continue
+			}
+			seen[pair] = true
+			result = append(result, outbound)
+		}
+	}
+	return result
+}
+
+// Does the network have any recurrent connections?  If not, it's purely
+// feed forward, and no neuron has anything to prime when it starts.
+func (cortex *Cortex) IsRecurrent() bool {
+	for _, neuron := range cortex.Neurons {
+		if len(neuron.RecurrentOutboundConnections()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Map each neuron UUID to the other neurons it primes before it starts.
+func (cortex *Cortex) primingNodeIds() map[string][]*NodeId {
+	primingNodeIds := make(map[string][]*NodeId)
+	for _, neuron := range cortex.Neurons {
+		for _, outbound := range neuron.RecurrentOutboundConnections() {
+			if outbound.NodeId.NodeType != NEURON {
+				continue
+			}
+			if outbound.NodeId.UUID == neuron.NodeId.UUID {
+				continue
+			}
+			primingNodeIds[neuron.NodeId.UUID] = append(primingNodeIds[neuron.NodeId.UUID], outbound.NodeId)
+		}
+	}
+	return primingNodeIds
+}
+
+// Find a cycle in the edges, starting the search from each of the given
+// UUIDs in turn.  Returns the UUIDs along the cycle, or nil if there is none.
+func findCycle(uuids []string, edges map[string][]*NodeId) []string {
+
+	visited := make(map[string]bool)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0)
+
+	var visit func(uuid string) []string
+	visit = func(uuid string) []string {
+		visited[uuid] = true
+		onStack[uuid] = true
+		stack = append(stack, uuid)
+		for _, nodeId := range edges[uuid] {
+			if onStack[nodeId.UUID] {
+				for i, stackUUID := range stack {
+					if stackUUID == nodeId.UUID {
+						return stack[i:]
+					}
+				}
+			}
+			if !visited[nodeId.UUID] {
+				if cycle := visit(nodeId.UUID); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		onStack[uuid] = false
+		stack = stack[:len(stack)-1]
+		return nil
+	}
+
+	for _, uuid := range uuids {
+		if !visited[uuid] {
+			if cycle := visit(uuid); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Remove the weakest connection from each cycle of recurrent connections,
+// which would otherwise deadlock priming, and return how many were removed.
+func (cortex *Cortex) BreakNonPrimableCycles() int {
+
+	// search in a fixed order, so the same connections get removed
+	// however the neurons happen to be ordered in the cortex
+	uuids := make([]string, 0)
+	neurons := make(map[string]*Neuron)
+	for _, neuron := range cortex.SortedNeurons() {
+		uuids = append(uuids, neuron.NodeId.UUID)
+		neurons[neuron.NodeId.UUID] = neuron
+	}
+
+	removed := 0
+	for {
+		cycle := findCycle(uuids, cortex.primingNodeIds())
+		if cycle == nil {
+			break
+		}
+
+		var weakestSource, weakestTarget *Neuron
+		weakestWeight := math.Inf(1)
+		for i, uuid := range cycle {
+			source := neurons[uuid]
+			target := neurons[cycle[(i+1)%len(cycle)]]
+			weight := 0.0
+			if inbound, ok := target.InboundUUIDMap()[uuid]; ok {
+				for _, w := range inbound.Weights {
+					weight = math.Max(weight, math.Abs(w))
+				}
+			}
+			if weight < weakestWeight {
+				weakestSource, weakestTarget = source, target
+				weakestWeight = weight
+			}
+		}
+
+		DisconnectOutbound(weakestSource, weakestTarget)
+		DisconnectInbound(weakestTarget, weakestSource)
+		removed += 1
+	}
+
+	return removed
+}
+
+// Identifies a connection by the nodes at either end
+type ConnectionRef struct {
+	From *NodeId
+	To   *NodeId
+}
+
+// Every pair of nodes with more than one connection between them.
+func (cortex *Cortex) FindDuplicateConnections() []ConnectionRef {
+
+	duplicates := make([]ConnectionRef, 0)
+	seen := make(map[string]bool)
+	found := make(map[string]bool)
+	check := func(from, to *NodeId) {
+		key := from.UUID + " -> " + to.UUID
+		if seen[key] && !found[key] {
+			duplicates = append(duplicates, ConnectionRef{From: from, To: to})
+			found[key] = true
+		}
+		seen[key] = true
+	}
+
+	for _, sensor := range cortex.Sensors {
+		for _, outbound := range sensor.Outbound {
+			check(sensor.NodeId, outbound.NodeId)
+		}
+	}
+	for _, neuron := range cortex.Neurons {
+		for _, outbound := range neuron.Outbound {
+			check(neuron.NodeId, outbound.NodeId)
+		}
+	}
+
+	// start over for the inbound side, since every connection is expected
+	// to show up on both sides once
+	seen = make(map[string]bool)
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			check(inbound.NodeId, neuron.NodeId)
+		}
+	}
+	for _, actuator := range cortex.Actuators {
+		for _, inbound := range actuator.Inbound {
+			check(inbound.NodeId, actuator.NodeId)
+		}
+	}
+
+	return duplicates
+}
+
+// Merge duplicate connections into one, summing their weights.
+func (cortex *Cortex) DeduplicateConnections() {
+
+	dedupeOutbound := func(outbound []*OutboundConnection) []*OutboundConnection {
+		result := make([]*OutboundConnection, 0)
+		seen := make(map[string]bool)
+		for _, connection := range outbound {
+			if !seen[connection.NodeId.UUID] {
+				result = append(result, connection)
+				seen[connection.NodeId.UUID] = true
+			}
+		}
+		return result
+	}
+
+	for _, sensor := range cortex.Sensors {
+		sensor.Outbound = dedupeOutbound(sensor.Outbound)
+	}
+
+	for _, neuron := range cortex.Neurons {
+		neuron.Outbound = dedupeOutbound(neuron.Outbound)
+
+		inbound := make([]*InboundConnection, 0)
+		merged := make(map[string]*InboundConnection)
+		for _, connection := range neuron.Inbound {
+			existing, ok := merged[connection.NodeId.UUID]
+			if !ok {
+				inbound = append(inbound, connection)
+				merged[connection.NodeId.UUID] = connection
+				continue
+			}
+			if len(existing.Weights) != len(connection.Weights) {
+				log.Panicf("Cannot merge connections from %v into %v with different numbers of weights",
+					connection.NodeId.UUID, neuron.NodeId.UUID)
+			}
+			for i, weight := range connection.Weights {
+				existing.Weights[i] += weight
+			}
+		}
+		neuron.Inbound = inbound
+	}
+
+	for _, actuator := range cortex.Actuators {
+		inbound := make([]*InboundConnection, 0)
+		seen := make(map[string]bool)
+		for _, connection := range actuator.Inbound {
+			if !seen[connection.NodeId.UUID] {
+				inbound = append(inbound, connection)
+				seen[connection.NodeId.UUID] = true
+			}
+		}
+		actuator.Inbound = inbound
+	}
+
+}
+
+// Return an error if a new connection from one node to another isn't allowed,
+// eg because of FeedForwardOnly or MaxFanIn.
+func (cortex *Cortex) CheckConnection(fromId, toId *NodeId) error {
+
+	from := cortex.findNodeId(fromId)
+	if from == nil || (from.NodeType != SENSOR && from.NodeType != NEURON) {
+		return fmt.Errorf("%v is not a sensor or neuron in this cortex", fromId)
+	}
+	to := cortex.findNodeId(toId)
+	if to == nil || (to.NodeType != NEURON && to.NodeType != ACTUATOR) {
+		return fmt.Errorf("%v is not a neuron or actuator in this cortex", toId)
+	}
+
+	if cortex.FeedForwardOnly && to.LayerIndex <= from.LayerIndex {
+		return fmt.Errorf("connection from %v to %v would be recurrent, and cortex is feed forward only",
+			from.UUID, to.UUID)
+	}
+
+	if cortex.MaxFanIn > 0 && to.NodeType == NEURON {
+		neuron := cortex.FindNeuron(to)
+		if len(neuron.Inbound) >= cortex.MaxFanIn {
+			return fmt.Errorf("%v already has %d inbound connections, the most allowed", to.UUID, len(neuron.Inbound))
+		}
+	}
+
+	return nil
+}
+
+// The number of feed forward connections on the longest sensor to actuator path.
+func (cortex *Cortex) PropagationSteps() int {
+
+	outboundNodeIds := cortex.outboundNodeIds()
+
+	// longest number of hops from each node to an actuator, or -1 if no
+	// actuator can be reached from it
+	steps := make(map[string]int)
+	var stepsFrom func(nodeId *NodeId) int
+	stepsFrom = func(nodeId *NodeId) int {
+		if nodeId.NodeType == ACTUATOR {
+			return 0
+		}
+		if result, ok := steps[nodeId.UUID]; ok {
+			return result
+		}
+		result := -1
+		for _, outbound := range outboundNodeIds[nodeId.UUID] {
+			if outbound.LayerIndex <= nodeId.LayerIndex {
+				continue
+			}
+			if downstream := stepsFrom(outbound); downstream >= 0 && downstream+1 > result {
+				result = downstream + 1
+			}
+		}
+		steps[nodeId.UUID] = result
+		return result
+	}
+
+	maxSteps := 0
+	for _, sensor := range cortex.Sensors {
+		if sensorSteps := stepsFrom(sensor.NodeId); sensorSteps > maxSteps {
+			maxSteps = sensorSteps
+		}
+	}
+	return maxSteps
+}
+
+// Move the connection from one node to oldTarget over to newTarget, keeping
+// its weights.
+func (cortex *Cortex) RerouteConnection(fromId, oldTargetId, newTargetId *NodeId) error {
+
+	from := cortex.FindConnector(fromId)
+	if from == nil {
+		return fmt.Errorf("%v is not a sensor or neuron in this cortex", fromId)
+	}
+	oldTarget := cortex.FindInboundConnector(oldTargetId)
+	if oldTarget == nil {
+		return fmt.Errorf("%v is not a neuron or actuator in this cortex", oldTargetId)
+	}
+	newTarget := cortex.FindInboundConnector(newTargetId)
+	if newTarget == nil {
+		return fmt.Errorf("%v is not a neuron or actuator in this cortex", newTargetId)
+	}
+	if oldTargetId.NodeType != newTargetId.NodeType {
+		return fmt.Errorf("cannot reroute a connection from a %v to a %v", oldTargetId.NodeType, newTargetId.NodeType)
+	}
+
+	var outbound *OutboundConnection
+	for _, connection := range from.outbound() {
+		if connection.NodeId.UUID == oldTargetId.UUID {
+			outbound = connection
+		}
+		if connection.NodeId.UUID == newTargetId.UUID {
+			return fmt.Errorf("%v is already connected to %v", fromId.UUID, newTargetId.UUID)
+		}
+	}
+	hasInbound := false
+	for _, connection := range oldTarget.inbound() {
+		if connection.NodeId.UUID == fromId.UUID {
+			hasInbound = true
+		}
+	}
+	if outbound == nil || !hasInbound {
+		return fmt.Errorf("%v is not connected to %v", fromId.UUID, oldTargetId.UUID)
+	}
+
+	if err := cortex.CheckConnection(fromId, newTargetId); err != nil {
+		return err
+	}
+
+	// swap the target of the outbound connection in place, so that the
+	// order of the sender's outbound connections doesn't change
+	newTargetConnectable := newTarget.(OutboundConnectable)
+	outbound.NodeId = newTargetConnectable.nodeId()
+	outbound.DataChan = newTargetConnectable.dataChan()
+
+	inbound := DisconnectInbound(oldTarget, from.(Disconnectable))
+	newTarget.setInbound(append(newTarget.inbound(), inbound))
+
+	return nil
+}
+
+// Replace a neuron with two parallel copies, splitting its outbound weights
+// between them so the network computes the same thing.  Returns the new one.
+func (cortex *Cortex) SplitNeuron(nodeId *NodeId) *Neuron {
+
+	neuron := cortex.FindNeuron(nodeId)
+	if neuron == nil {
+		log.Panicf("No neuron with UUID %v", nodeId.UUID)
+	}
+
+	split := &Neuron{
+		ActivationFunction: neuron.ActivationFunction,
+		NodeId:             NewNeuronId(NewUuid(), neuron.NodeId.LayerIndex),
+		Bias:               neuron.Bias,
+		TimeConstant:       neuron.TimeConstant,
+		InputTimeout:       neuron.InputTimeout,
+	}
+	split.Init()
+
+	// outbound connections are made directly rather than with
+	// ConnectOutbound, since the targets' data channels will be nil if the
+	// network has already been shut down.  They get filled in again the
+	// next time it's run.
+	connect := func(from OutboundConnector, to OutboundConnectable) {
+		connection := &OutboundConnection{
+			NodeId:   to.nodeId(),
+			DataChan: to.dataChan(),
+		}
+		from.setOutbound(append(from.outbound(), connection))
+	}
+
+	for _, inbound := range neuron.Inbound {
+		weights := make([]float64, len(inbound.Weights))
+		copy(weights, inbound.Weights)
+		if inbound.NodeId.UUID == neuron.NodeId.UUID {
+			split.ConnectInboundWeighted(split, weights)
+			connect(split, split)
+			continue
+		}
+		sender := cortex.FindConnector(inbound.NodeId)
+		if sender == nil {
+			log.Panicf("No sensor or neuron with UUID %v", inbound.NodeId.UUID)
+		}
+		split.ConnectInboundWeighted(sender.(InboundConnectable), weights)
+		connect(sender, split)
+	}
+
+	for _, outbound := range neuron.Outbound {
+		if outbound.NodeId.NodeType != NEURON || outbound.NodeId.UUID == neuron.NodeId.UUID {
+			continue
+		}
+		target := cortex.FindNeuron(outbound.NodeId)
+		for _, inbound := range target.Inbound {
+			if inbound.NodeId.UUID != neuron.NodeId.UUID {
+				continue
+			}
+			weights := make([]float64, len(inbound.Weights))
+			for i, _ := range inbound.Weights {
+				inbound.Weights[i] /= 2
+				weights[i] = inbound.Weights[i]
+			}
+			target.ConnectInboundWeighted(split, weights)
+		}
+		connect(split, target)
+	}
+
+	split.Cortex = cortex
+	cortex.Neurons = append(cortex.Neurons, split)
+	return split
+
+}
+
+// Map each actuator's UUID to the sensors which can affect it on the same pass.
+func (cortex *Cortex) ActuatorInputDependencies() map[string][]string {
+
+	feedForward := make(map[string][]*NodeId)
+	outboundNodeIds := cortex.outboundNodeIds()
+	for _, nodeId := range append(cortex.SensorNodeIds(), cortex.NeuronNodeIds()...) {
+		for _, outbound := range outboundNodeIds[nodeId.UUID] {
+			if outbound.LayerIndex > nodeId.LayerIndex {
+				feedForward[nodeId.UUID] = append(feedForward[nodeId.UUID], outbound)
+			}
+		}
+	}
+
+	dependencies := make(map[string][]string)
+	for _, actuator := range cortex.Actuators {
+		dependencies[actuator.NodeId.UUID] = make([]string, 0)
+	}
+	for _, sensor := range cortex.Sensors {
+		for uuid, _ := range reachableUUIDs(sensor.NodeId.UUID, feedForward) {
+			if _, ok := dependencies[uuid]; ok {
+				dependencies[uuid] = append(dependencies[uuid], sensor.NodeId.UUID)
+			}
+		}
+	}
+	return dependencies
+}
+
+// Fold the remove neuron into the keep neuron, adding its outbound weights
+// onto keep's.  Only preserves the output if both compute the same function.
+func (cortex *Cortex) MergeNeurons(keep, remove *NodeId) error {
+
+	keepNeuron := cortex.FindNeuron(keep)
+	if keepNeuron == nil {
+		return fmt.Errorf("%v is not a neuron in this cortex", keep)
+	}
+	removeNeuron := cortex.FindNeuron(remove)
+	if removeNeuron == nil {
+		return fmt.Errorf("%v is not a neuron in this cortex", remove)
+	}
+	if keep.UUID == remove.UUID {
+		return fmt.Errorf("cannot merge %v into itself", keep.UUID)
+	}
+
+	keepOutbound := make(map[string]bool)
+	for _, outbound := range keepNeuron.Outbound {
+		keepOutbound[outbound.NodeId.UUID] = true
+	}
+
+	for _, outbound := range removeNeuron.Outbound {
+		if outbound.NodeId.UUID == remove.UUID {
+			continue
+		}
+		target := cortex.FindInboundConnector(outbound.NodeId)
+		if target == nil {
+			return fmt.Errorf("%v is not a neuron or actuator in this cortex", outbound.NodeId)
+		}
+
+		var fromKeep *InboundConnection
+		for _, inbound := range target.inbound() {
+			if inbound.NodeId.UUID == keep.UUID {
+				fromKeep = inbound
+			}
+		}
+		_, isNeuron := target.(*Neuron)
+
+		for _, inbound := range target.inbound() {
+			if inbound.NodeId.UUID != remove.UUID {
+				continue
+			}
+			if isNeuron && fromKeep != nil {
+				if len(fromKeep.Weights) != len(inbound.Weights) {
+					return fmt.Errorf("cannot merge connections into %v with different numbers of weights", outbound.NodeId.UUID)
+				}
+				for i, weight := range inbound.Weights {
+					fromKeep.Weights[i] += weight
+				}
+			} else {
+				// an actuator can't sum its inputs, but it records a single
+				// message into every inbound slot from the same sender
+				inbound.NodeId = keepNeuron.NodeId
+			}
+		}
+		if isNeuron && fromKeep != nil {
+			DisconnectInbound(target, removeNeuron)
+		}
+
+		if !keepOutbound[outbound.NodeId.UUID] {
+			keepNeuron.Outbound = append(keepNeuron.Outbound, &OutboundConnection{
+				NodeId:   outbound.NodeId,
+				DataChan: outbound.DataChan,
+			})
+			keepOutbound[outbound.NodeId.UUID] = true
+		}
+	}
+
+	for _, inbound := range removeNeuron.Inbound {
+		if sender := cortex.FindConnector(inbound.NodeId); sender != nil {
+			DisconnectOutbound(sender, removeNeuron)
+		}
+	}
+
+	neurons := make([]*Neuron, 0)
+	for _, neuron := range cortex.Neurons {
+		if neuron != removeNeuron {
+			neurons = append(neurons, neuron)
+		}
+	}
+	cortex.Neurons = neurons
+
+	return nil
+}
+
+// Resize the input of a single sensor network, truncating the weights fed by
+// the sensor or padding them with small random ones.
+func (cortex *Cortex) AdaptInputSize(newVectorLength int) error {
+
+	if len(cortex.Sensors) != 1 {
+		return fmt.Errorf("cannot adapt input size of a cortex with %d sensors", len(cortex.Sensors))
+	}
+	if newVectorLength < 1 {
+		return fmt.Errorf("invalid vector length: %d", newVectorLength)
+	}
+	sensor := cortex.Sensors[0]
+
+	for _, neuron := range cortex.Neurons {
+		inbound, ok := neuron.InboundUUIDMap()[sensor.NodeId.UUID]
+		if !ok {
+			continue
+		}
+		weights := make([]float64, newVectorLength)
+		for i, _ := range weights {
+			if i < len(inbound.Weights) {
+				weights[i] = inbound.Weights[i]
+			} else {
+				weights[i] = RandomInRange(-0.01, 0.01)
+			}
+		}
+		inbound.Weights = weights
+	}
+	sensor.VectorLength = newVectorLength
+
+	return nil
+
+}
+
+// A copy of the network with the smallest connections pruned for as long as
+// its Fitness stays at or above minFitness.
+func (cortex *Cortex) Minimize(samples []*TrainingSample, minFitness float64) *Cortex {
+
+	minimized := cortex.Copy()
+
+	for {
+		removed := false
+		for _, ref := range minimized.prunableConnections() {
+			trial := minimized.Copy()
+			trial.removeConnection(ref)
+			trial.removeDeadNeurons()
+			if trial.Validate() && trial.Fitness(samples) >= minFitness {
+				minimized = trial
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			return minimized
+		}
+	}
+
+}
+
+// The connections into neurons which could be removed without leaving the
+// neuron with no inputs from other nodes, ordered by the total magnitude of their weights.
+func (cortex *Cortex) prunableConnections() []ConnectionRef {
+	refs := make([]ConnectionRef, 0)
+	magnitudes := make([]float64, 0)
+	for _, neuron := range cortex.SortedNeurons() {
+		external := 0
+		for _, inbound := range neuron.Inbound {
+			if inbound.NodeId.UUID != neuron.NodeId.UUID {
+				external += 1
+			}
+		}
+		for _, inbound := range neuron.Inbound {
+			if external < 2 && inbound.NodeId.UUID != neuron.NodeId.UUID {
+				continue
+			}
+			magnitude := 0.0
+			for _, weight := range inbound.Weights {
+				magnitude += math.Abs(weight)
+			}
+			refs = append(refs, ConnectionRef{From: inbound.NodeId, To: neuron.NodeId})
+			magnitudes = append(magnitudes, magnitude)
+		}
+	}
+	indexes := make([]int, len(refs))
+	for i, _ := range indexes {
+		indexes[i] = i
+	}
+	sort.Stable(byValue{indexes, magnitudes})
+	sorted := make([]ConnectionRef, len(refs))
+	for i, index := range indexes {
+		sorted[i] = refs[index]
+	}
+	return sorted
+}
+
+func (cortex *Cortex) removeConnection(ref ConnectionRef) {
+	neuron := cortex.FindNeuron(ref.To)
+	DisconnectInbound(neuron, ref.From)
+	if sender := cortex.FindConnector(ref.From); sender != nil {
+		DisconnectOutbound(sender, neuron)
+	}
+}
+
+// Repeatedly remove neurons which send their output nowhere but to themselves.
+func (cortex *Cortex) removeDeadNeurons() {
+	for {
+		var dead *Neuron
+		for _, neuron := range cortex.Neurons {
+			live := false
+			for _, outbound := range neuron.Outbound {
+				if outbound.NodeId.UUID != neuron.NodeId.UUID {
+					live = true
+				}
+			}
+			if !live {
+				dead = neuron
+				break
+			}
+		}
+		if dead == nil {
+			return
+		}
+		for _, inbound := range dead.Inbound {
+			if sender := cortex.FindConnector(inbound.NodeId); sender != nil {
+				DisconnectOutbound(sender, dead)
+			}
+		}
+		neurons := make([]*Neuron, 0)
+		for _, neuron := range cortex.Neurons {
+			if neuron != dead {
+				neurons = append(neurons, neuron)
+			}
+		}
+		cortex.Neurons = neurons
+	}
+}
+
+// Add a random connection that passes CheckConnection and doesn't deadlock
+// priming, or return an error if there's nothing left to connect.
+func (cortex *Cortex) AddConnection() (*InboundConnection, error) {
+	return cortex.addConnection(rand.New(rand.NewSource(rand.Int63())))
+}
+
+func (cortex *Cortex) addConnection(rng *rand.Rand) (*InboundConnection, error) {
+
+	type candidate struct {
+		from   OutboundConnector
+		fromId *NodeId
+		to     *Neuron
+		length int
+	}
+
+	senders := make([]candidate, 0)
+	for _, sensor := range cortex.Sensors {
+		senders = append(senders, candidate{from: sensor, fromId: sensor.NodeId, length: sensor.VectorLength})
+	}
+	for _, neuron := range cortex.Neurons {
+		senders = append(senders, candidate{from: neuron, fromId: neuron.NodeId, length: 1})
+	}
+
+	priming := cortex.primingNodeIds()
+	candidates := make([]candidate, 0)
+	for _, sender := range senders {
+		connected := make(map[string]bool)
+		for _, outbound := range sender.from.outbound() {
+			connected[outbound.NodeId.UUID] = true
+		}
+		for _, neuron := range cortex.Neurons {
+			if neuron.NodeId.UUID == sender.fromId.UUID || connected[neuron.NodeId.UUID] {
+				continue
+			}
+			if _, ok := neuron.InboundUUIDMap()[sender.fromId.UUID]; ok {
+				continue
+			}
+			if cortex.CheckConnection(sender.fromId, neuron.NodeId) != nil {
+				continue
+			}
+			recurrent := sender.fromId.NodeType == NEURON && neuron.NodeId.LayerIndex <= sender.fromId.LayerIndex
+			if recurrent && reachableUUIDs(neuron.NodeId.UUID, priming)[sender.fromId.UUID] {
+				continue
+			}
+			sender.to = neuron
+			candidates = append(candidates, sender)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, errors.New("there are no unconnected pairs of nodes left")
+	}
+
+	chosen := candidates[rng.Intn(len(candidates))]
+	chosen.from.setOutbound(append(chosen.from.outbound(), &OutboundConnection{
+		NodeId:   chosen.to.NodeId,
+		DataChan: chosen.to.DataChan,
+	}))
+	// same range as RandomWeights, but drawn from rng
+	weights := make([]float64, chosen.length)
+	for i, _ := range weights {
+		weights[i] = (rng.Float64()*2 - 1) * math.Pi
+	}
+	return chosen.to.ConnectInboundWeighted(chosen.fromId, weights), nil
+
+}
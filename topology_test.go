@@ -0,0 +1,518 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func neuronUUIDs(cortex *Cortex) []string {
+	uuids := make([]string, 0)
+	for _, neuron := range cortex.Neurons {
+		uuids = append(uuids, neuron.NodeId.UUID)
+	}
+	sort.Strings(uuids)
+	return uuids
+}
+
+func TestSubgraph(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	hiddenNeuron2 := xnorCortex.Neurons[1]
+	outputNeuron := xnorCortex.Neurons[2]
+
+	// everything lies between the sensor and the actuator
+	subgraph, err := xnorCortex.Subgraph(xnorCortex.Sensors[0].NodeId, xnorCortex.Actuators[0].NodeId)
+	assert.True(t, err == nil)
+	assert.Equals(t, len(subgraph.Neurons), 3)
+	assert.Equals(t, len(subgraph.Sensors), 1)
+	assert.True(t, subgraph.Verify(XnorTrainingSamples()))
+
+	// only hidden-neuron1 and output-neuron lie on this path, and the
+	// input from hidden-neuron2 is replaced with a sensor
+	subgraph, err = xnorCortex.Subgraph(hiddenNeuron1.NodeId, outputNeuron.NodeId)
+	assert.True(t, err == nil)
+	assert.Equals(t, neuronUUIDs(subgraph), []string{"hidden-neuron1", "output-neuron"})
+	assert.Equals(t, len(subgraph.Sensors), 2)
+	assert.Equals(t, len(subgraph.Actuators), 1)
+	assert.True(t, subgraph.Validate())
+
+	// driving the boundary with the values hidden-neuron2 would have sent
+	// gives the same output as the whole network
+	inputs := make([][]float64, 0)
+	for _, sensor := range subgraph.Sensors {
+		switch sensor.NodeId.UUID {
+		case "sensor":
+			inputs = append(inputs, []float64{0, 0})
+		case hiddenNeuron2.NodeId.UUID:
+			inputs = append(inputs, []float64{Sigmoid(hiddenNeuron2.Bias)})
+		}
+	}
+	outputs, err := subgraph.Activate(inputs)
+	assert.True(t, err == nil)
+	expected, _ := xnorCortex.Activate([][]float64{[]float64{0, 0}})
+	assert.True(t, vectorEqualsWithMaxDelta(outputs[0], expected[0], 1e-6))
+
+	// the original is untouched
+	assert.Equals(t, len(xnorCortex.Neurons), 3)
+	assert.Equals(t, len(outputNeuron.Inbound), 2)
+
+	// there is no path between the two hidden neurons
+	_, err = xnorCortex.Subgraph(hiddenNeuron1.NodeId, hiddenNeuron2.NodeId)
+	assert.True(t, err != nil)
+
+}
+
+func TestBreakNonPrimableCycles(t *testing.T) {
+
+	sensor := &Sensor{
+		NodeId:       NewSensorId("sensor", 0.0),
+		VectorLength: 1,
+	}
+	sensor.Init()
+	neuron1 := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("neuron1", 0.25),
+	}
+	neuron1.Init()
+	neuron2 := &Neuron{
+		ActivationFunction: EncodableIdentity(),
+		NodeId:             NewNeuronId("neuron2", 0.25),
+	}
+	neuron2.Init()
+	actuator := &Actuator{
+		NodeId:       NewActuatorId("actuator", 0.5),
+		VectorLength: 1,
+	}
+	actuator.Init()
+
+	sensor.ConnectOutbound(neuron1)
+	neuron1.ConnectInboundWeighted(sensor, []float64{1})
+	sensor.ConnectOutbound(neuron2)
+	neuron2.ConnectInboundWeighted(sensor, []float64{1})
+
+	// the two neurons are in the same layer, so both connections are
+	// recurrent and each neuron blocks trying to prime the other
+	neuron1.ConnectOutbound(neuron2)
+	neuron2.ConnectInboundWeighted(neuron1, []float64{0.5})
+	neuron2.ConnectOutbound(neuron1)
+	neuron1.ConnectInboundWeighted(neuron2, []float64{-0.1})
+
+	// a connection to itself is primed without blocking
+	neuron1.ConnectOutbound(neuron1)
+	neuron1.ConnectInboundWeighted(neuron1, []float64{0.2})
+
+	neuron1.ConnectOutbound(actuator)
+	actuator.ConnectInbound(neuron1)
+
+	cortex := &Cortex{
+		NodeId: NewCortexId("cortex"),
+	}
+	cortex.SetSensors([]*Sensor{sensor})
+	cortex.SetNeurons([]*Neuron{neuron1, neuron2})
+	cortex.SetActuators([]*Actuator{actuator})
+
+	assert.Equals(t, cortex.BreakNonPrimableCycles(), 1)
+
+	// the weaker connection from neuron2 back to neuron1 is gone
+	assert.Equals(t, len(neuron2.Outbound), 0)
+	_, ok := neuron1.InboundUUIDMap()["neuron2"]
+	assert.False(t, ok)
+	_, ok = neuron2.InboundUUIDMap()["neuron1"]
+	assert.True(t, ok)
+	_, ok = neuron1.InboundUUIDMap()["neuron1"]
+	assert.True(t, ok)
+
+	// and the network can now run
+	outputs, err := cortex.Activate([][]float64{[]float64{1}})
+	assert.True(t, err == nil)
+	assert.Equals(t, outputs, [][]float64{[]float64{1}})
+
+	// nothing left to break
+	assert.Equals(t, cortex.BreakNonPrimableCycles(), 0)
+	assert.Equals(t, XnorCortex().BreakNonPrimableCycles(), 0)
+
+}
+
+func TestDuplicateConnections(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	assert.Equals(t, len(xnorCortex.FindDuplicateConnections()), 0)
+
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	outputNeuron := xnorCortex.Neurons[2]
+	hiddenNeuron1.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(hiddenNeuron1, []float64{5})
+
+	duplicates := xnorCortex.FindDuplicateConnections()
+	assert.Equals(t, len(duplicates), 1)
+	assert.Equals(t, duplicates[0].From.UUID, "hidden-neuron1")
+	assert.Equals(t, duplicates[0].To.UUID, "output-neuron")
+
+	xnorCortex.DeduplicateConnections()
+	assert.Equals(t, len(xnorCortex.FindDuplicateConnections()), 0)
+	assert.Equals(t, len(hiddenNeuron1.Outbound), 1)
+	assert.Equals(t, len(outputNeuron.Inbound), 2)
+	inbound := outputNeuron.InboundUUIDMap()["hidden-neuron1"]
+	assert.True(t, VectorEquals(inbound.Weights, []float64{25}))
+
+	// the merged network still runs
+	_, err := xnorCortex.Activate([][]float64{[]float64{1, 1}})
+	assert.True(t, err == nil)
+
+}
+
+func TestRecurrentConnections(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	assert.False(t, xnorCortex.IsRecurrent())
+	assert.Equals(t, len(xnorCortex.RecurrentConnections()), 0)
+
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	hiddenNeuron2 := xnorCortex.Neurons[1]
+	outputNeuron := xnorCortex.Neurons[2]
+
+	// back to an earlier layer (twice), to the same layer, and to itself
+	outputNeuron.ConnectOutbound(hiddenNeuron1)
+	hiddenNeuron1.ConnectInboundWeighted(outputNeuron, []float64{0.5})
+	outputNeuron.ConnectOutbound(hiddenNeuron1)
+	hiddenNeuron1.ConnectInboundWeighted(outputNeuron, []float64{0.5})
+	hiddenNeuron1.ConnectOutbound(hiddenNeuron2)
+	hiddenNeuron2.ConnectInboundWeighted(hiddenNeuron1, []float64{0.5})
+	outputNeuron.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(outputNeuron, []float64{0.5})
+
+	assert.True(t, xnorCortex.IsRecurrent())
+	targets := make([]string, 0)
+	for _, connection := range xnorCortex.RecurrentConnections() {
+		targets = append(targets, connection.NodeId.UUID)
+	}
+	sort.Strings(targets)
+	assert.Equals(t, targets, []string{"hidden-neuron1", "hidden-neuron2", "output-neuron"})
+
+}
+
+func TestCheckConnection(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	sensor := xnorCortex.Sensors[0]
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	hiddenNeuron2 := xnorCortex.Neurons[1]
+	outputNeuron := xnorCortex.Neurons[2]
+	actuator := xnorCortex.Actuators[0]
+
+	// recurrent connections are fine by default
+	assert.True(t, xnorCortex.CheckConnection(outputNeuron.NodeId, hiddenNeuron1.NodeId) == nil)
+	assert.True(t, xnorCortex.CheckConnection(hiddenNeuron1.NodeId, hiddenNeuron1.NodeId) == nil)
+
+	xnorCortex.FeedForwardOnly = true
+	assert.True(t, xnorCortex.CheckConnection(outputNeuron.NodeId, hiddenNeuron1.NodeId) != nil)
+	assert.True(t, xnorCortex.CheckConnection(hiddenNeuron1.NodeId, hiddenNeuron2.NodeId) != nil)
+	assert.True(t, xnorCortex.CheckConnection(hiddenNeuron1.NodeId, hiddenNeuron1.NodeId) != nil)
+	assert.True(t, xnorCortex.CheckConnection(sensor.NodeId, outputNeuron.NodeId) == nil)
+	assert.True(t, xnorCortex.CheckConnection(hiddenNeuron1.NodeId, actuator.NodeId) == nil)
+
+	// connections have to run from a sensor or neuron to a neuron or actuator
+	assert.True(t, xnorCortex.CheckConnection(actuator.NodeId, outputNeuron.NodeId) != nil)
+	assert.True(t, xnorCortex.CheckConnection(outputNeuron.NodeId, sensor.NodeId) != nil)
+	assert.True(t, xnorCortex.CheckConnection(NewNeuronId("missing", 0.3), actuator.NodeId) != nil)
+
+	// the flag survives a copy
+	assert.True(t, xnorCortex.Copy().FeedForwardOnly)
+
+}
+
+func TestPropagationSteps(t *testing.T) {
+
+	// sensor -> hidden layer -> output neuron -> actuator
+	assert.Equals(t, XnorCortex().PropagationSteps(), 3)
+	assert.Equals(t, BasicCortex().PropagationSteps(), 2)
+
+	// a recurrent connection doesn't lengthen the path
+	xnorCortex := XnorCortex()
+	outputNeuron := xnorCortex.Neurons[2]
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	outputNeuron.ConnectOutbound(hiddenNeuron1)
+	hiddenNeuron1.ConnectInboundWeighted(outputNeuron, []float64{1})
+	assert.Equals(t, xnorCortex.PropagationSteps(), 3)
+
+	// but a shortcut past the hidden layer doesn't shorten it
+	sensor := xnorCortex.Sensors[0]
+	sensor.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(sensor, []float64{1, 1})
+	assert.Equals(t, xnorCortex.PropagationSteps(), 3)
+
+}
+
+func TestMaxFanIn(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	sensor := xnorCortex.Sensors[0]
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	outputNeuron := xnorCortex.Neurons[2]
+
+	// zero means unlimited
+	assert.True(t, xnorCortex.CheckConnection(sensor.NodeId, outputNeuron.NodeId) == nil)
+
+	// the output neuron already has two inbound connections, the hidden
+	// neurons only one each
+	xnorCortex.MaxFanIn = 2
+	assert.True(t, xnorCortex.CheckConnection(sensor.NodeId, outputNeuron.NodeId) != nil)
+	assert.True(t, xnorCortex.CheckConnection(outputNeuron.NodeId, hiddenNeuron1.NodeId) == nil)
+
+	// actuators aren't limited
+	assert.True(t, xnorCortex.CheckConnection(hiddenNeuron1.NodeId, xnorCortex.Actuators[0].NodeId) == nil)
+
+	assert.Equals(t, xnorCortex.Copy().MaxFanIn, 2)
+
+}
+
+func TestRerouteConnection(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	sensor := xnorCortex.Sensors[0]
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	hiddenNeuron2 := xnorCortex.Neurons[1]
+	outputNeuron := xnorCortex.Neurons[2]
+	actuator := xnorCortex.Actuators[0]
+
+	// move hidden-neuron1 -> output-neuron over to hidden-neuron2
+	err := xnorCortex.RerouteConnection(hiddenNeuron1.NodeId, outputNeuron.NodeId, hiddenNeuron2.NodeId)
+	assert.True(t, err == nil)
+
+	_, ok := outputNeuron.InboundUUIDMap()["hidden-neuron1"]
+	assert.False(t, ok)
+	inbound, ok := hiddenNeuron2.InboundUUIDMap()["hidden-neuron1"]
+	assert.True(t, ok)
+	assert.True(t, VectorEquals(inbound.Weights, []float64{20}))
+	assert.Equals(t, len(hiddenNeuron1.Outbound), 1)
+	assert.Equals(t, hiddenNeuron1.Outbound[0].NodeId.UUID, "hidden-neuron2")
+	assert.True(t, hiddenNeuron1.Outbound[0].DataChan == hiddenNeuron2.DataChan)
+	assert.Equals(t, len(xnorCortex.FindDuplicateConnections()), 0)
+
+	// can't duplicate an existing connection
+	err = xnorCortex.RerouteConnection(sensor.NodeId, hiddenNeuron1.NodeId, hiddenNeuron2.NodeId)
+	assert.True(t, err != nil)
+
+	// the connection has to exist
+	err = xnorCortex.RerouteConnection(hiddenNeuron1.NodeId, outputNeuron.NodeId, hiddenNeuron2.NodeId)
+	assert.True(t, err != nil)
+
+	// can't swap a neuron for an actuator
+	err = xnorCortex.RerouteConnection(sensor.NodeId, hiddenNeuron1.NodeId, actuator.NodeId)
+	assert.True(t, err != nil)
+
+	// and the new connection has to be allowed
+	xnorCortex.FeedForwardOnly = true
+	err = xnorCortex.RerouteConnection(outputNeuron.NodeId, actuator.NodeId, actuator.NodeId)
+	assert.True(t, err != nil)
+	err = xnorCortex.RerouteConnection(hiddenNeuron2.NodeId, outputNeuron.NodeId, hiddenNeuron1.NodeId)
+	assert.True(t, err != nil)
+	_, ok = outputNeuron.InboundUUIDMap()["hidden-neuron2"]
+	assert.True(t, ok)
+
+}
+
+func TestSplitNeuron(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+
+	// split a hidden neuron, and the output neuron which feeds the actuator
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[2].Bias = -18
+	reference := xnorCortex.Copy()
+	split := xnorCortex.SplitNeuron(xnorCortex.Neurons[0].NodeId)
+	assert.Equals(t, len(xnorCortex.Neurons), 4)
+	assert.Equals(t, split.NodeId.LayerIndex, 0.25)
+	xnorCortex.SplitNeuron(xnorCortex.Neurons[2].NodeId)
+	assert.Equals(t, len(xnorCortex.Neurons), 5)
+	assert.True(t, xnorCortex.Validate())
+	assert.True(t, xnorCortex.OutputsMatch(reference, examples, 1e-9))
+
+	// the weights into the output neuron were split in two
+	inbound := xnorCortex.Neurons[2].InboundUUIDMap()["hidden-neuron1"]
+	assert.True(t, VectorEquals(inbound.Weights, []float64{10}))
+	inbound = xnorCortex.Neurons[2].InboundUUIDMap()[split.NodeId.UUID]
+	assert.True(t, VectorEquals(inbound.Weights, []float64{10}))
+
+	// a neuron with a connection to itself, after the network has run
+	xnorCortex = XnorCortex()
+	hiddenNeuron2 := xnorCortex.Neurons[1]
+	hiddenNeuron2.ConnectOutbound(hiddenNeuron2)
+	hiddenNeuron2.ConnectInboundWeighted(hiddenNeuron2, []float64{-5})
+	reference = xnorCortex.Copy()
+	xnorCortex.Fitness(examples)
+	split = xnorCortex.SplitNeuron(hiddenNeuron2.NodeId)
+	_, ok := split.InboundUUIDMap()[split.NodeId.UUID]
+	assert.True(t, ok)
+	_, ok = split.InboundUUIDMap()[hiddenNeuron2.NodeId.UUID]
+	assert.False(t, ok)
+	assert.True(t, xnorCortex.OutputsMatch(reference, examples, 1e-9))
+
+}
+
+func TestActuatorInputDependencies(t *testing.T) {
+
+	dependencies := XnorCortex().ActuatorInputDependencies()
+	assert.Equals(t, dependencies, map[string][]string{"actuator": []string{"sensor"}})
+
+	// sensor1 feeds both actuators through neuron1, but sensor2 only feeds
+	// actuator2 through neuron2
+	sensor1 := &Sensor{NodeId: NewSensorId("sensor1", 0.0), VectorLength: 1}
+	sensor1.Init()
+	sensor2 := &Sensor{NodeId: NewSensorId("sensor2", 0.0), VectorLength: 1}
+	sensor2.Init()
+	neuron1 := &Neuron{ActivationFunction: EncodableIdentity(), NodeId: NewNeuronId("neuron1", 0.25)}
+	neuron1.Init()
+	neuron2 := &Neuron{ActivationFunction: EncodableIdentity(), NodeId: NewNeuronId("neuron2", 0.25)}
+	neuron2.Init()
+	actuator1 := &Actuator{NodeId: NewActuatorId("actuator1", 0.5), VectorLength: 1}
+	actuator1.Init()
+	actuator2 := &Actuator{NodeId: NewActuatorId("actuator2", 0.5), VectorLength: 2}
+	actuator2.Init()
+
+	sensor1.ConnectOutbound(neuron1)
+	neuron1.ConnectInboundWeighted(sensor1, []float64{1})
+	sensor2.ConnectOutbound(neuron2)
+	neuron2.ConnectInboundWeighted(sensor2, []float64{1})
+	neuron1.ConnectOutbound(actuator1)
+	actuator1.ConnectInbound(neuron1)
+	neuron1.ConnectOutbound(actuator2)
+	actuator2.ConnectInbound(neuron1)
+	neuron2.ConnectOutbound(actuator2)
+	actuator2.ConnectInbound(neuron2)
+
+	// a recurrent connection doesn't make actuator1 depend on sensor2
+	neuron2.ConnectOutbound(neuron1)
+	neuron1.ConnectInboundWeighted(neuron2, []float64{1})
+
+	cortex := &Cortex{NodeId: NewCortexId("cortex")}
+	cortex.SetSensors([]*Sensor{sensor1, sensor2})
+	cortex.SetNeurons([]*Neuron{neuron1, neuron2})
+	cortex.SetActuators([]*Actuator{actuator1, actuator2})
+
+	dependencies = cortex.ActuatorInputDependencies()
+	assert.Equals(t, dependencies["actuator1"], []string{"sensor1"})
+	assert.Equals(t, dependencies["actuator2"], []string{"sensor1", "sensor2"})
+
+}
+
+func TestMergeNeurons(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[2].Bias = -18
+	reference := xnorCortex.Copy()
+
+	// splitting and merging back again gets back to where we started
+	hiddenNeuron2 := xnorCortex.Neurons[1]
+	split := xnorCortex.SplitNeuron(hiddenNeuron2.NodeId)
+	assert.True(t, xnorCortex.MergeNeurons(hiddenNeuron2.NodeId, split.NodeId) == nil)
+	assert.Equals(t, len(xnorCortex.Neurons), 3)
+	assert.Equals(t, len(xnorCortex.Sensors[0].Outbound), 2)
+	outputNeuron := xnorCortex.Neurons[2]
+	assert.Equals(t, len(outputNeuron.Inbound), 2)
+	inbound := outputNeuron.InboundUUIDMap()["hidden-neuron2"]
+	assert.True(t, VectorEquals(inbound.Weights, []float64{20}))
+	assert.True(t, xnorCortex.OutputsMatch(reference, examples, 1e-9))
+
+	// merging into a neuron that doesn't feed the actuator yet
+	split = xnorCortex.SplitNeuron(outputNeuron.NodeId)
+	assert.True(t, xnorCortex.MergeNeurons(split.NodeId, outputNeuron.NodeId) == nil)
+	assert.Equals(t, len(xnorCortex.Neurons), 3)
+	assert.Equals(t, xnorCortex.Actuators[0].Inbound[0].NodeId.UUID, split.NodeId.UUID)
+	assert.True(t, xnorCortex.OutputsMatch(reference, examples, 1e-9))
+
+	assert.True(t, xnorCortex.MergeNeurons(split.NodeId, split.NodeId) != nil)
+	assert.True(t, xnorCortex.MergeNeurons(split.NodeId, xnorCortex.Sensors[0].NodeId) != nil)
+
+}
+
+func TestAdaptInputSize(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	assert.True(t, xnorCortex.AdaptInputSize(3) == nil)
+	assert.Equals(t, xnorCortex.Sensors[0].VectorLength, 3)
+
+	hiddenNeuron1 := xnorCortex.NeuronUUIDMap()["hidden-neuron1"]
+	weights := hiddenNeuron1.Inbound[0].Weights
+	assert.Equals(t, len(weights), 3)
+	assert.Equals(t, weights[0:2], []float64{20, 20})
+	assert.True(t, math.Abs(weights[2]) <= 0.01)
+	assert.True(t, xnorCortex.Validate())
+
+	// the new input barely matters, so it still computes xnor
+	outputs, err := xnorCortex.Activate([][]float64{[]float64{1, 1, 1}})
+	assert.True(t, err == nil)
+	assert.True(t, outputs[0][0] > 0.9)
+
+	assert.True(t, xnorCortex.AdaptInputSize(0) != nil)
+
+}
+
+func TestMinimize(t *testing.T) {
+
+	examples := XnorTrainingSamples()
+
+	// an extra hidden neuron which barely affects the output
+	xnorCortex := XnorCortex()
+	sensor := xnorCortex.Sensors[0]
+	outputNeuron := xnorCortex.NeuronUUIDMap()["output-neuron"]
+	extra := xnorCortex.CreateNeuronInLayer(0.25)
+	ConnectOutbound(sensor, extra)
+	extra.ConnectInboundWeighted(sensor, []float64{1, 1})
+	extra.ConnectOutbound(outputNeuron)
+	outputNeuron.ConnectInboundWeighted(extra, []float64{0.001})
+	assert.True(t, xnorCortex.Validate())
+
+	fitness := xnorCortex.Fitness(examples)
+	minimized := xnorCortex.Minimize(examples, fitness/2)
+
+	assert.Equals(t, len(minimized.Neurons), 3)
+	assert.Equals(t, minimized.ConnectionCount(), 5)
+	assert.True(t, minimized.FindNeuron(extra.NodeId) == nil)
+	assert.True(t, minimized.Fitness(examples) >= fitness/2)
+
+	// the original is untouched
+	assert.Equals(t, len(xnorCortex.Neurons), 4)
+
+}
+
+func TestAddConnection(t *testing.T) {
+
+	rand.Seed(1)
+
+	// the only forward connection left in xnor is sensor -> output-neuron
+	xnorCortex := XnorCortex()
+	xnorCortex.FeedForwardOnly = true
+	inbound, err := xnorCortex.AddConnection()
+	assert.True(t, err == nil)
+	assert.Equals(t, inbound.NodeId.UUID, "sensor")
+	assert.Equals(t, len(inbound.Weights), 2)
+	assert.Equals(t, xnorCortex.ConnectionCount(), 6)
+	_, err = xnorCortex.AddConnection()
+	assert.True(t, err != nil)
+
+	// recurrent connections are allowed, as long as they can be primed:
+	// output-neuron -> both hidden neurons, and one way between the hidden
+	// neurons
+	xnorCortex.FeedForwardOnly = false
+	for i := 0; i < 3; i++ {
+		inbound, err = xnorCortex.AddConnection()
+		assert.True(t, err == nil)
+		assert.Equals(t, len(inbound.Weights), 1)
+	}
+	_, err = xnorCortex.AddConnection()
+	assert.True(t, err != nil)
+	assert.Equals(t, xnorCortex.ConnectionCount(), 9)
+	assert.Equals(t, len(xnorCortex.FindDuplicateConnections()), 0)
+	assert.True(t, xnorCortex.Validate())
+
+	// and the network still runs
+	_, err = xnorCortex.Activate([][]float64{[]float64{1, 1}})
+	assert.True(t, err == nil)
+
+}
@@ -0,0 +1,86 @@
+package neurgo
+
+import (
+	"log"
+	"sync"
+)
+
+type TraceEventType string
+
+const (
+	TRACE_FIRED TraceEventType = "FIRED" // a neuron fired, producing Values
+	TRACE_SENT  TraceEventType = "SENT"  // a node sent Values to ReceiverId
+)
+
+// Something that happened while tracing a pass through the network
+type TraceEvent struct {
+	Type       TraceEventType
+	NodeId     *NodeId
+	ReceiverId *NodeId // only set for TRACE_SENT
+	Values     []float64
+}
+
+// Feed one set of inputs through the network, returning every firing and
+// message in the order they happened.
+func (cortex *Cortex) Trace(inputs [][]float64) []TraceEvent {
+
+	var mutex sync.Mutex
+	events := make([]TraceEvent, 0)
+	record := func(event TraceEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, event)
+	}
+
+	observer := &runObserver{
+		neuronFired: func(neuron *Neuron, weightedSum, output float64) {
+			record(TraceEvent{
+				Type:   TRACE_FIRED,
+				NodeId: neuron.NodeId,
+				Values: []float64{output},
+			})
+		},
+		messageSent: func(senderId, receiverId *NodeId, inputs []float64) {
+			record(TraceEvent{
+				Type:       TRACE_SENT,
+				NodeId:     senderId,
+				ReceiverId: receiverId,
+				Values:     inputs,
+			})
+		},
+	}
+
+	cortex.observe(observer, func() {
+		if _, err := cortex.Activate(inputs); err != nil {
+			log.Panicf("Unable to trace cortex: %v", err)
+		}
+	})
+
+	return events
+
+}
+
+// Feed one set of inputs through the network, returning the neurons in the
+// order they fired.
+func (cortex *Cortex) ObservedFiringOrder(inputs [][]float64) ([]*NodeId, error) {
+
+	var mutex sync.Mutex
+	firingOrder := make([]*NodeId, 0)
+	observer := &runObserver{
+		neuronFired: func(neuron *Neuron, weightedSum, output float64) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			firingOrder = append(firingOrder, neuron.NodeId)
+		},
+	}
+
+	var err error
+	cortex.observe(observer, func() {
+		_, err = cortex.Activate(inputs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return firingOrder, nil
+
+}
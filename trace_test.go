@@ -0,0 +1,78 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"sort"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	events := xnorCortex.Trace([][]float64{[]float64{0, 1}})
+
+	hidden1 := Sigmoid(20*0 + 20*1 - 30)
+	hidden2 := Sigmoid(-20*0 - 20*1 + 10)
+	output := Sigmoid(20*hidden1 + 20*hidden2 - 10)
+	expectedOutputs := map[string]float64{
+		"hidden-neuron1": hidden1,
+		"hidden-neuron2": hidden2,
+		"output-neuron":  output,
+	}
+
+	// every neuron fires once, with the hidden layer before the output
+	fired := make([]string, 0)
+	position := make(map[string]int)
+	for i, event := range events {
+		if event.Type != TRACE_FIRED {
+			continue
+		}
+		uuid := event.NodeId.UUID
+		fired = append(fired, uuid)
+		position[uuid] = i
+		assert.True(t, EqualsWithMaxDelta(event.Values[0], expectedOutputs[uuid], 1e-12))
+	}
+	assert.Equals(t, len(fired), 3)
+	assert.Equals(t, fired[2], "output-neuron")
+
+	// the sensor sends to both hidden neurons, each of which sends to the
+	// output neuron, which sends to the actuator
+	sent := make(map[string][]float64)
+	for i, event := range events {
+		if event.Type != TRACE_SENT {
+			continue
+		}
+		key := event.NodeId.UUID + " -> " + event.ReceiverId.UUID
+		sent[key] = event.Values
+		if event.NodeId.NodeType == NEURON {
+			assert.True(t, i > position[event.NodeId.UUID])
+		}
+		if event.ReceiverId.NodeType == NEURON {
+			assert.True(t, i < position[event.ReceiverId.UUID])
+		}
+	}
+	assert.Equals(t, len(sent), 5)
+	assert.Equals(t, sent["sensor -> hidden-neuron1"], []float64{0, 1})
+	assert.Equals(t, sent["hidden-neuron2 -> output-neuron"], []float64{hidden2})
+	assert.Equals(t, sent["output-neuron -> actuator"], []float64{output})
+
+	// tracing leaves nothing installed on the cortex
+	assert.True(t, xnorCortex.observer == nil)
+
+}
+
+func TestObservedFiringOrder(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	firingOrder, err := xnorCortex.ObservedFiringOrder([][]float64{[]float64{1, 0}})
+	assert.True(t, err == nil)
+	assert.Equals(t, len(firingOrder), 3)
+	assert.Equals(t, firingOrder[2].UUID, "output-neuron")
+	hiddenUUIDs := []string{firingOrder[0].UUID, firingOrder[1].UUID}
+	sort.Strings(hiddenUUIDs)
+	assert.Equals(t, hiddenUUIDs, []string{"hidden-neuron1", "hidden-neuron2"})
+
+	_, err = xnorCortex.ObservedFiringOrder([][]float64{[]float64{1}})
+	assert.True(t, err != nil)
+
+}
@@ -2,6 +2,10 @@ package neurgo
 
 import (
 	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
 )
 
 type TrainingSample struct {
@@ -19,6 +23,123 @@ func (t *TrainingSample) String() string {
 		t.ExpectedOutputs)
 }
 
+// The fitness of a cortex after each iteration of training.
+type TrainingHistory struct {
+	Fitness []float64
+}
+
+func (history *TrainingHistory) Record(fitness float64) {
+	history.Fitness = append(history.Fitness, fitness)
+}
+
+// Called after each training iteration with the best fitness reached so far.
+type ProgressFunc func(iteration int, bestFitness float64)
+
 type Trainer interface {
 	Train(cortex *Cortex, examples []*TrainingSample) *Cortex
 }
+
+// Train the weights and biases in place with rank-weighted natural evolution
+// strategies, recording into History and reporting to progress if set, and
+// return the final fitness.
+func ESTrain(cortex *Cortex, samples []*TrainingSample, populationSize int, sigma, learningRate float64, iterations int, rng *rand.Rand, progress ProgressFunc) float64 {
+
+	if populationSize < 2 {
+		log.Panicf("Population size must be at least 2, got %d", populationSize)
+	}
+
+	parameters := cortex.GetParameters()
+	frozen := cortex.frozenParameters()
+	candidate := cortex.Copy()
+
+	noise := make([][]float64, populationSize)
+	fitnesses := make([]float64, populationSize)
+	bestFitness := 0.0
+
+	for iteration := 0; iteration < iterations; iteration++ {
+
+		for i := 0; i < populationSize; i++ {
+			// use mirrored pairs of perturbations, which cuts down
+			// on the noise in the estimated direction
+			noise[i] = make([]float64, len(parameters))
+			perturbed := make([]float64, len(parameters))
+			for j, parameter := range parameters {
+				if frozen[j] {
+					noise[i][j] = 0
+				} else if i%2 == 0 {
+					noise[i][j] = rng.NormFloat64()
+				} else {
+					noise[i][j] = -1 * noise[i-1][j]
+				}
+				perturbed[j] = parameter + sigma*noise[i][j]
+			}
+			if err := candidate.SetParameters(perturbed); err != nil {
+				log.Panicf("Could not set parameters: %v", err)
+			}
+			fitnesses[i] = candidate.Fitness(samples)
+		}
+
+		utilities := centeredRanks(fitnesses)
+		step := learningRate / (float64(populationSize) * sigma)
+		for i, utility := range utilities {
+			for j, _ := range parameters {
+				parameters[j] += step * utility * noise[i][j]
+			}
+		}
+
+		if cortex.History != nil || progress != nil {
+			if err := candidate.SetParameters(parameters); err != nil {
+				log.Panicf("Could not set parameters: %v", err)
+			}
+			fitness := candidate.Fitness(samples)
+			if cortex.History != nil {
+				cortex.History.Record(fitness)
+			}
+			if progress != nil {
+				bestFitness = math.Max(bestFitness, fitness)
+				progress(iteration, bestFitness)
+			}
+		}
+
+	}
+
+	if err := cortex.SetParameters(parameters); err != nil {
+		log.Panicf("Could not set parameters: %v", err)
+	}
+	return cortex.Fitness(samples)
+
+}
+
+// Replace each value with its rank, scaled to lie between -0.5 for the
+// lowest and 0.5 for the highest.
+func centeredRanks(values []float64) []float64 {
+	indexes := make([]int, len(values))
+	for i, _ := range indexes {
+		indexes[i] = i
+	}
+	sort.Sort(byValue{indexes, values})
+
+	ranks := make([]float64, len(values))
+	for rank, index := range indexes {
+		ranks[index] = float64(rank)/float64(len(values)-1) - 0.5
+	}
+	return ranks
+}
+
+// Sorts indexes by the values they point to
+type byValue struct {
+	indexes []int
+	values  []float64
+}
+
+func (b byValue) Len() int {
+	return len(b.indexes)
+}
+
+func (b byValue) Swap(i, j int) {
+	b.indexes[i], b.indexes[j] = b.indexes[j], b.indexes[i]
+}
+
+func (b byValue) Less(i, j int) bool {
+	return b.values[b.indexes[i]] < b.values[b.indexes[j]]
+}
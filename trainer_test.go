@@ -0,0 +1,95 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// An untrained xnor cortex whose weights and biases are drawn from rng, so
+// that training it is repeatable.
+func xnorCortexUntrainedFrom(rng *rand.Rand) *Cortex {
+	cortex := XnorCortexUntrained()
+	parameters := cortex.GetParameters()
+	for i, _ := range parameters {
+		parameters[i] = rng.Float64()*2*math.Pi - math.Pi
+	}
+	cortex.SetParameters(parameters)
+	return cortex
+}
+
+func TestESTrain(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(2))
+
+	examples := XnorTrainingSamples()
+	xnorCortex := xnorCortexUntrainedFrom(rng)
+	initialFitness := xnorCortex.Fitness(examples)
+
+	fitness := ESTrain(xnorCortex, examples, 20, 0.5, 0.5, 200, rng, nil)
+	assert.True(t, fitness > initialFitness)
+
+	// a sum of squares error below 0.01 means every output is on the
+	// right side of 0.5
+	assert.True(t, fitness > 100)
+	assert.Equals(t, xnorCortex.Fitness(examples), fitness)
+
+}
+
+func TestTrainingHistory(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(2))
+
+	examples := XnorTrainingSamples()
+	xnorCortex := xnorCortexUntrainedFrom(rng)
+	xnorCortex.History = &TrainingHistory{}
+
+	fitness := ESTrain(xnorCortex, examples, 4, 0.5, 0.5, 5, rng, nil)
+	assert.Equals(t, len(xnorCortex.History.Fitness), 5)
+	assert.Equals(t, xnorCortex.History.Fitness[4], fitness)
+
+	dir, err := ioutil.TempDir("", "neurgo")
+	assert.True(t, err == nil)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "cortex.json")
+
+	assert.True(t, xnorCortex.MarshalJSONToFile(filename) == nil)
+	loaded, err := NewCortexFromJSONFile(filename)
+	assert.True(t, err == nil)
+	assert.Equals(t, loaded.History.Fitness, xnorCortex.History.Fitness)
+
+}
+
+func TestESTrainProgress(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(2))
+
+	examples := XnorTrainingSamples()
+	xnorCortex := xnorCortexUntrainedFrom(rng)
+	xnorCortex.History = &TrainingHistory{}
+
+	iterations := make([]int, 0)
+	bestFitnesses := make([]float64, 0)
+	progress := func(iteration int, bestFitness float64) {
+		iterations = append(iterations, iteration)
+		bestFitnesses = append(bestFitnesses, bestFitness)
+	}
+	ESTrain(xnorCortex, examples, 4, 0.5, 0.5, 20, rng, progress)
+
+	assert.Equals(t, len(iterations), 20)
+	best := 0.0
+	for i, fitness := range xnorCortex.History.Fitness {
+		best = math.Max(best, fitness)
+		assert.Equals(t, iterations[i], i)
+		assert.Equals(t, bestFitnesses[i], best)
+		if i > 0 {
+			assert.True(t, bestFitnesses[i] >= bestFitnesses[i-1])
+		}
+	}
+	assert.True(t, bestFitnesses[19] > bestFitnesses[0])
+
+}
@@ -0,0 +1,264 @@
+package neurgo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+type WeightInitializer func(fromId, toId *NodeId, index int) float64
+
+// Set every weight in the network to the value returned by the initializer.
+func (cortex *Cortex) InitWeights(initializer WeightInitializer) {
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			for i, _ := range inbound.Weights {
+				inbound.Weights[i] = initializer(inbound.NodeId, neuron.NodeId, i)
+			}
+		}
+	}
+}
+
+// Replace every weight in the network with the result of calling fn on it,
+// eg to add noise, flip signs or rescale.
+func (cortex *Cortex) MapWeights(fn func(w float64) float64) {
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			for i, weight := range inbound.Weights {
+				inbound.Weights[i] = fn(weight)
+			}
+		}
+	}
+}
+
+// Nudge each weight with the given probability by up to magnitude, skipping
+// Frozen neurons.
+func (cortex *Cortex) PerturbWeights(probability float64, magnitude float64) {
+	cortex.perturbWeights(probability, magnitude, rand.New(rand.NewSource(rand.Int63())))
+}
+
+func (cortex *Cortex) perturbWeights(probability float64, magnitude float64, rng *rand.Rand) {
+	for _, neuron := range cortex.Neurons {
+		if neuron.Frozen {
+			continue
+		}
+		for _, inbound := range neuron.Inbound {
+			for i, weight := range inbound.Weights {
+				if rng.Float64() < probability {
+					weight += (rng.Float64()*2 - 1) * magnitude
+					inbound.Weights[i] = Saturate(weight, -2*math.Pi, 2*math.Pi)
+				}
+			}
+		}
+	}
+}
+
+// Round every weight to the nearest of 2^bits evenly spaced values.
+func (cortex *Cortex) QuantizeWeights(bits int) {
+
+	if bits < 1 {
+		log.Panicf("Cannot quantize weights to %d bits", bits)
+	}
+
+	maxWeight := float64(0)
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			for _, weight := range inbound.Weights {
+				maxWeight = math.Max(maxWeight, math.Abs(weight))
+			}
+		}
+	}
+	if maxWeight == 0 {
+		return
+	}
+
+	levels := math.Pow(2, float64(bits))
+	step := (2 * maxWeight) / (levels - 1)
+
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			for i, weight := range inbound.Weights {
+				level := math.Floor((weight+maxWeight)/step + 0.5)
+				inbound.Weights[i] = -1*maxWeight + level*step
+			}
+		}
+	}
+
+}
+
+// Clamp every weight and bias in the network into [lower, upper], eg to
+// rein in parameters that have exploded after an aggressive training step.
+func (cortex *Cortex) ClampParameters(lower, upper float64) {
+
+	if lower > upper {
+		log.Panicf("Lower bound %v is above upper bound %v", lower, upper)
+	}
+
+	for _, neuron := range cortex.Neurons {
+		neuron.Bias = Saturate(neuron.Bias, lower, upper)
+		for _, inbound := range neuron.Inbound {
+			for i, weight := range inbound.Weights {
+				inbound.Weights[i] = Saturate(weight, lower, upper)
+			}
+		}
+	}
+
+}
+
+// All weights and biases, neuron by neuron in SortedNeurons order.
+func (cortex *Cortex) GetParameters() []float64 {
+	parameters := make([]float64, 0)
+	for _, neuron := range cortex.SortedNeurons() {
+		for _, inbound := range neuron.Inbound {
+			parameters = append(parameters, inbound.Weights...)
+		}
+		parameters = append(parameters, neuron.Bias)
+	}
+	return parameters
+}
+
+// A hash of the network's parameters, as laid out by GetParameters.
+func (cortex *Cortex) ParameterChecksum() uint64 {
+	hash := fnv.New64a()
+	bytes := make([]byte, 8)
+	for _, parameter := range cortex.GetParameters() {
+		binary.LittleEndian.PutUint64(bytes, math.Float64bits(parameter))
+		hash.Write(bytes)
+	}
+	return hash.Sum64()
+}
+
+// Which of the parameters, laid out as in GetParameters, belong to Frozen
+// neurons.
+func (cortex *Cortex) frozenParameters() []bool {
+	frozen := make([]bool, 0)
+	for _, neuron := range cortex.SortedNeurons() {
+		for _, inbound := range neuron.Inbound {
+			for _ = range inbound.Weights {
+				frozen = append(frozen, neuron.Frozen)
+			}
+		}
+		frozen = append(frozen, neuron.Frozen)
+	}
+	return frozen
+}
+
+// Set the Frozen flag on every neuron in the given layer, so that training
+// leaves them alone, eg while pretraining the layers after it.
+func (cortex *Cortex) FreezeLayer(layerIndex float64) {
+	cortex.setLayerFrozen(layerIndex, true)
+}
+
+// Clear the Frozen flag on every neuron in the given layer
+func (cortex *Cortex) ThawLayer(layerIndex float64) {
+	cortex.setLayerFrozen(layerIndex, false)
+}
+
+func (cortex *Cortex) setLayerFrozen(layerIndex float64, frozen bool) {
+	for _, neuron := range cortex.Neurons {
+		if neuron.NodeId.LayerIndex == layerIndex {
+			neuron.Frozen = frozen
+		}
+	}
+}
+
+// Set all of the parameters from a vector laid out like GetParameters.
+func (cortex *Cortex) SetParameters(parameters []float64) error {
+
+	numParameters := 0
+	for _, neuron := range cortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			numParameters += len(inbound.Weights)
+		}
+		numParameters += 1
+	}
+	if len(parameters) != numParameters {
+		return fmt.Errorf("got %d parameters, cortex has %d", len(parameters), numParameters)
+	}
+
+	i := 0
+	for _, neuron := range cortex.SortedNeurons() {
+		for _, inbound := range neuron.Inbound {
+			i += copy(inbound.Weights, parameters[i:])
+		}
+		neuron.Bias = parameters[i]
+		i += 1
+	}
+	return nil
+}
+
+// Take a gradient descent step of size lr, skipping Frozen neurons.
+func (cortex *Cortex) ApplyGradients(grads map[string][]float64, biasGrads map[string]float64, lr float64) {
+
+	neuronUUIDMap := cortex.NeuronUUIDMap()
+	for uuid, _ := range grads {
+		if _, ok := neuronUUIDMap[uuid]; !ok {
+			log.Panicf("Got weight gradients for unknown neuron: %v", uuid)
+		}
+	}
+	for uuid, _ := range biasGrads {
+		if _, ok := neuronUUIDMap[uuid]; !ok {
+			log.Panicf("Got bias gradient for unknown neuron: %v", uuid)
+		}
+	}
+
+	for _, neuron := range cortex.Neurons {
+		if neuron.Frozen {
+			continue
+		}
+		if weightGrads, ok := grads[neuron.NodeId.UUID]; ok {
+			numWeights := 0
+			for _, inbound := range neuron.Inbound {
+				numWeights += len(inbound.Weights)
+			}
+			if len(weightGrads) != numWeights {
+				log.Panicf("Got %d weight gradients for %v, which has %d weights", len(weightGrads), neuron.NodeId.UUID, numWeights)
+			}
+			i := 0
+			for _, inbound := range neuron.Inbound {
+				for j, _ := range inbound.Weights {
+					inbound.Weights[j] -= lr * weightGrads[i]
+					i += 1
+				}
+			}
+		}
+		if biasGrad, ok := biasGrads[neuron.NodeId.UUID]; ok {
+			neuron.Bias -= lr * biasGrad
+		}
+	}
+
+}
+
+// Nudge the inbound weights of every neuron that's an exact copy (same
+// layer, inbound weights, bias and activation) of an earlier one.
+func (cortex *Cortex) BreakSymmetry(rng *rand.Rand) {
+
+	jitter := 1e-3
+
+	seen := make(map[string]bool)
+	for _, neuron := range cortex.SortedNeurons() {
+		keyParts := []string{
+			fmt.Sprint(neuron.NodeId.LayerIndex),
+			fmt.Sprint(neuron.Bias),
+			neuron.ActivationFunction.Name,
+		}
+		for _, inbound := range neuron.Inbound {
+			keyParts = append(keyParts, fmt.Sprintf("%v:%v", inbound.NodeId.UUID, inbound.Weights))
+		}
+		key := strings.Join(keyParts, " ")
+		if !seen[key] {
+			seen[key] = true
+			continue
+		}
+		for _, inbound := range neuron.Inbound {
+			for i, _ := range inbound.Weights {
+				inbound.Weights[i] += jitter * (2*rng.Float64() - 1)
+			}
+		}
+	}
+
+}
@@ -0,0 +1,270 @@
+package neurgo
+
+import (
+	"github.com/couchbaselabs/go.assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestInitWeights(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+
+	xnorCortex.InitWeights(func(fromId, toId *NodeId, index int) float64 {
+		return float64(index)
+	})
+
+	for _, neuron := range xnorCortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			for i, weight := range inbound.Weights {
+				assert.Equals(t, weight, float64(i))
+			}
+		}
+	}
+
+	hiddenNeuron := xnorCortex.Neurons[0]
+	assert.True(t, VectorEquals(hiddenNeuron.Inbound[0].Weights, []float64{0, 1}))
+
+	// the initializer is told which connection each weight belongs to
+	xnorCortex.InitWeights(func(fromId, toId *NodeId, index int) float64 {
+		if fromId.NodeType == SENSOR {
+			return 1
+		}
+		return -1
+	})
+	assert.True(t, VectorEquals(hiddenNeuron.Inbound[0].Weights, []float64{1, 1}))
+	outputNeuron := xnorCortex.Neurons[2]
+	assert.True(t, VectorEquals(outputNeuron.Inbound[0].Weights, []float64{-1}))
+
+}
+
+func TestPerturbWeights(t *testing.T) {
+
+	rand.Seed(1)
+
+	xnorCortex := XnorCortex()
+	xnorCortex.MapWeights(func(w float64) float64 {
+		return w / 10
+	})
+	original := xnorCortex.GetParameters()
+
+	xnorCortex.PerturbWeights(0.0, 1.0)
+	assert.True(t, VectorEquals(xnorCortex.GetParameters(), original))
+
+	xnorCortex.PerturbWeights(1.0, 1.0)
+	for _, neuron := range xnorCortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			originalInbound := XnorCortex().NeuronUUIDMap()[neuron.NodeId.UUID].InboundUUIDMap()[inbound.NodeId.UUID]
+			for i, weight := range inbound.Weights {
+				assert.True(t, weight != originalInbound.Weights[i]/10)
+				assert.True(t, math.Abs(weight-originalInbound.Weights[i]/10) <= 1.0)
+			}
+		}
+	}
+
+	// the weights stay within bounds, and frozen neurons are left alone
+	xnorCortex.Neurons[0].Frozen = true
+	frozenWeights := append([]float64{}, xnorCortex.Neurons[0].Inbound[0].Weights...)
+	for i := 0; i < 10; i++ {
+		xnorCortex.PerturbWeights(1.0, 5.0)
+	}
+	assert.Equals(t, xnorCortex.Neurons[0].Inbound[0].Weights, frozenWeights)
+	for _, weight := range xnorCortex.Neurons[1].Inbound[0].Weights {
+		assert.True(t, math.Abs(weight) <= 2*math.Pi)
+	}
+
+}
+
+func TestQuantizeWeights(t *testing.T) {
+
+	// an xnor network whose weights are a little off the round numbers
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[0].Inbound[0].Weights = []float64{21, 19}
+	xnorCortex.Neurons[1].Inbound[0].Weights = []float64{-20, -18.5}
+	xnorCortex.Neurons[2].Inbound[0].Weights = []float64{20}
+	xnorCortex.Neurons[2].Inbound[1].Weights = []float64{19.5}
+
+	xnorCortex.QuantizeWeights(4)
+
+	// 16 levels spanning [-21, 21]
+	step := 42.0 / 15.0
+	for _, neuron := range xnorCortex.Neurons {
+		for _, inbound := range neuron.Inbound {
+			for _, weight := range inbound.Weights {
+				level := (weight + 21) / step
+				assert.True(t, EqualsWithMaxDelta(level, math.Floor(level+0.5), 1e-9))
+			}
+		}
+	}
+	assert.True(t, EqualsWithMaxDelta(xnorCortex.Neurons[0].Inbound[0].Weights[1], 18.2, 1e-9))
+
+	// the network still computes xnor
+	for _, example := range XnorTrainingSamples() {
+		outputs, err := xnorCortex.Activate(example.SampleInputs)
+		assert.True(t, err == nil)
+		assert.True(t, vectorEqualsWithMaxDelta(outputs[0], example.ExpectedOutputs[0], 0.1))
+	}
+
+}
+
+func TestClampParameters(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	xnorCortex.Neurons[0].Inbound[0].Weights = []float64{1e6, 20}
+	xnorCortex.Neurons[0].Bias = math.Inf(-1)
+	xnorCortex.Neurons[1].Inbound[0].Weights = []float64{-20, -75}
+	xnorCortex.Neurons[2].Bias = 60
+
+	xnorCortex.ClampParameters(-50, 50)
+
+	assert.True(t, VectorEquals(xnorCortex.Neurons[0].Inbound[0].Weights, []float64{50, 20}))
+	assert.Equals(t, xnorCortex.Neurons[0].Bias, -50.0)
+	assert.True(t, VectorEquals(xnorCortex.Neurons[1].Inbound[0].Weights, []float64{-20, -50}))
+	assert.Equals(t, xnorCortex.Neurons[1].Bias, 10.0)
+	assert.Equals(t, xnorCortex.Neurons[2].Bias, 50.0)
+
+}
+
+func TestGetSetParameters(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+
+	// neurons in layer then UUID order, each neuron's weights then bias
+	expected := []float64{
+		20, 20, -30,
+		-20, -20, 10,
+		20, 20, -10,
+	}
+	assert.Equals(t, xnorCortex.GetParameters(), expected)
+
+	parameters := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	assert.True(t, xnorCortex.SetParameters(parameters) == nil)
+	assert.Equals(t, xnorCortex.GetParameters(), parameters)
+	assert.True(t, VectorEquals(xnorCortex.Neurons[1].Inbound[0].Weights, []float64{4, 5}))
+	assert.Equals(t, xnorCortex.Neurons[2].Bias, 9.0)
+
+	// the wrong number of parameters is rejected without changing anything
+	assert.True(t, xnorCortex.SetParameters([]float64{1, 2}) != nil)
+	assert.Equals(t, xnorCortex.GetParameters(), parameters)
+
+}
+
+func TestMapWeights(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	xnorCortex.MapWeights(func(w float64) float64 { return -1 * w })
+
+	assert.True(t, VectorEquals(xnorCortex.Neurons[0].Inbound[0].Weights, []float64{-20, -20}))
+	assert.True(t, VectorEquals(xnorCortex.Neurons[1].Inbound[0].Weights, []float64{20, 20}))
+	assert.True(t, VectorEquals(xnorCortex.Neurons[2].Inbound[0].Weights, []float64{-20}))
+	assert.True(t, VectorEquals(xnorCortex.Neurons[2].Inbound[1].Weights, []float64{-20}))
+
+	// biases aren't weights
+	assert.Equals(t, xnorCortex.Neurons[0].Bias, -30.0)
+
+}
+
+func TestApplyGradients(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+
+	grads := map[string][]float64{
+		"hidden-neuron1": []float64{1, -2},
+		"output-neuron":  []float64{4, 0},
+	}
+	biasGrads := map[string]float64{
+		"hidden-neuron1": 10,
+	}
+	xnorCortex.ApplyGradients(grads, biasGrads, 0.5)
+
+	assert.True(t, VectorEquals(xnorCortex.Neurons[0].Inbound[0].Weights, []float64{19.5, 21}))
+	assert.Equals(t, xnorCortex.Neurons[0].Bias, -35.0)
+	assert.True(t, VectorEquals(xnorCortex.Neurons[2].Inbound[0].Weights, []float64{18}))
+	assert.True(t, VectorEquals(xnorCortex.Neurons[2].Inbound[1].Weights, []float64{20}))
+	assert.Equals(t, xnorCortex.Neurons[2].Bias, -10.0)
+
+	// hidden-neuron2 wasn't in either map
+	assert.True(t, VectorEquals(xnorCortex.Neurons[1].Inbound[0].Weights, []float64{-20, -20}))
+	assert.Equals(t, xnorCortex.Neurons[1].Bias, 10.0)
+
+}
+
+func TestFreezeLayer(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	xnorCortex.SetParameters([]float64{
+		0.5, -0.3, 0.1,
+		-0.7, 0.2, -0.4,
+		0.8, 0.6, 0.3,
+	})
+	examples := XnorTrainingSamples()
+
+	xnorCortex.FreezeLayer(0.25)
+	assert.True(t, xnorCortex.Neurons[0].Frozen)
+	assert.True(t, xnorCortex.Neurons[1].Frozen)
+	assert.False(t, xnorCortex.Neurons[2].Frozen)
+	assert.True(t, xnorCortex.Copy().Neurons[1].Frozen)
+
+	before := xnorCortex.GetParameters()
+	for i := 0; i < 10; i++ {
+		grads, biasGrads, err := xnorCortex.ComputeGradients(examples)
+		assert.True(t, err == nil)
+		xnorCortex.ApplyGradients(grads, biasGrads, 0.1)
+	}
+	ESTrain(xnorCortex, examples, 4, 0.1, 0.1, 2, rand.New(rand.NewSource(1)), nil)
+
+	// only the output neuron's weights and bias moved
+	after := xnorCortex.GetParameters()
+	assert.Equals(t, after[0:6], before[0:6])
+	for i := 6; i < 9; i++ {
+		assert.True(t, after[i] != before[i])
+	}
+
+	xnorCortex.ThawLayer(0.25)
+	assert.False(t, xnorCortex.Neurons[0].Frozen)
+	grads, biasGrads, _ := xnorCortex.ComputeGradients(examples)
+	xnorCortex.ApplyGradients(grads, biasGrads, 0.1)
+	assert.True(t, xnorCortex.GetParameters()[0] != before[0])
+
+}
+
+func TestParameterChecksum(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	checksum := xnorCortex.ParameterChecksum()
+	assert.Equals(t, XnorCortex().ParameterChecksum(), checksum)
+
+	// survives a round trip through json
+	assert.Equals(t, xnorCortex.Copy().ParameterChecksum(), checksum)
+
+	xnorCortex.Neurons[1].Inbound[0].Weights[1] += 1e-9
+	assert.True(t, xnorCortex.ParameterChecksum() != checksum)
+
+}
+
+func TestBreakSymmetry(t *testing.T) {
+
+	xnorCortex := XnorCortex()
+	hiddenNeuron1 := xnorCortex.Neurons[0]
+	hiddenNeuron2 := xnorCortex.Neurons[1]
+	hiddenNeuron2.Inbound[0].Weights = []float64{20, 20}
+
+	// with different biases they compute different things, so they're left
+	// alone
+	xnorCortex.BreakSymmetry(rand.New(rand.NewSource(1)))
+	assert.True(t, VectorEquals(hiddenNeuron2.Inbound[0].Weights, []float64{20, 20}))
+
+	hiddenNeuron2.Bias = hiddenNeuron1.Bias
+	xnorCortex.BreakSymmetry(rand.New(rand.NewSource(1)))
+
+	// the first of the two is left alone, and the other is nudged
+	assert.True(t, VectorEquals(hiddenNeuron1.Inbound[0].Weights, []float64{20, 20}))
+	assert.False(t, VectorEquals(hiddenNeuron2.Inbound[0].Weights, []float64{20, 20}))
+	assert.True(t, vectorEqualsWithMaxDelta(hiddenNeuron2.Inbound[0].Weights, []float64{20, 20}, 1e-3))
+
+	// the output neuron's two weights are identical, but that's fine
+	assert.True(t, VectorEquals(xnorCortex.Neurons[2].Inbound[0].Weights, []float64{20}))
+	assert.True(t, VectorEquals(xnorCortex.Neurons[2].Inbound[1].Weights, []float64{20}))
+
+}